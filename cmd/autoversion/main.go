@@ -4,14 +4,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/trondhindenes/autoversion/internal/apply"
+	"github.com/trondhindenes/autoversion/internal/changelog"
 	"github.com/trondhindenes/autoversion/internal/config"
 	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/emit"
 	"github.com/trondhindenes/autoversion/internal/ghactions"
 	"github.com/trondhindenes/autoversion/internal/version"
 )
@@ -22,6 +28,60 @@ var (
 	cfgFile    string
 	configFlag []string
 
+	// root command flags
+	refFlag    string
+	branchFlag string
+	formatFlag string
+	emitFlag   string
+	outputFlag string
+
+	// history command flags
+	historyBranch    string
+	historyLimit     int
+	historyOutputFmt string
+	historyCheck     bool
+
+	// find command flags
+	findOutputFmt string
+
+	// branches command flags
+	branchesOutputFmt string
+
+	// schema command flags
+	schemaOutput bool
+
+	// apply command flags
+	applyDryRun bool
+
+	// tag and bump command flags
+	bumpTag    bool
+	tagMessage string
+	tagSign    bool
+	tagForce   bool
+	tagDryRun  bool
+	tagPush    bool
+	tagRemote  string
+
+	// changelog command flags
+	changelogFrom  string
+	changelogTo    string
+	changelogWrite bool
+	changelogFile  string
+
+	// release-notes command flags
+	releaseNotesFrom      string
+	releaseNotesTo        string
+	releaseNotesOutputFmt string
+
+	// go command flags
+	goVars    []string
+	goPackage string
+	goOut     string
+
+	// docker-tags command flags
+	dockerImages    []string
+	dockerOutputFmt string
+
 	// gh-versions command flags
 	ghWorkflow  string
 	ghJob       string
@@ -40,7 +100,9 @@ It calculates versions for the main branch (e.g., 1.0.0, 1.0.1) and prerelease v
 	schemaCmd = &cobra.Command{
 		Use:   "schema",
 		Short: "Generate JSON schema for the configuration file",
-		Run:   runSchema,
+		Long: `Generates the JSON schema for the configuration file, or with --output for the
+JSON output (mode "json").`,
+		Run: runSchema,
 	}
 	versionCmd = &cobra.Command{
 		Use:   "version",
@@ -49,6 +111,254 @@ It calculates versions for the main branch (e.g., 1.0.0, 1.0.1) and prerelease v
 			fmt.Println(Version)
 		},
 	}
+	historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "Show the calculated version of every commit on a branch",
+		Long: `Calculates the version of each commit along a branch's first-parent history.
+
+For the main branch, every commit on its first-parent history is listed. For other
+branches, only the commits since the branch diverged from main are listed.
+Use --check to verify that versions never go backwards and are never reused.
+
+Examples:
+  # Versions on the current branch
+  autoversion history
+
+  # Last 20 versions on main as JSON Lines
+  autoversion history -b main -L 20 -o jsonl
+
+  # Fail if the version mapping ever went backwards or produced duplicates
+  autoversion history -b main --check`,
+		Run: runHistory,
+	}
+	findCmd = &cobra.Command{
+		Use:   "find <version>",
+		Short: "Find the commit that produced a version",
+		Long: `Finds the commit(s) whose calculated version is exactly the given version under the
+current configuration, along with the branch and the tag the version is based on.
+
+The main branch history is searched, followed by the local and remote branches.
+For prerelease versions, only branches whose sanitized name matches the prerelease
+label are searched.
+
+Examples:
+  # Find the commit a bug report refers to
+  autoversion find 1.4.37
+
+  # Find a feature branch build
+  autoversion find 1.5.0-login-fix.3 -o json`,
+		Args: cobra.ExactArgs(1),
+		Run:  runFind,
+	}
+	branchesCmd = &cobra.Command{
+		Use:   "branches",
+		Short: "Show versions for all local and remote branches",
+		Long: `Calculates the version of every local and remote-tracking branch in one pass.
+
+For each branch, the overview shows how many commits it is ahead of and behind the main
+branch, and whether its base is outdated according to outdatedBaseCheckMode.
+Remote branches that point to the same commit as the local branch are left out.
+
+Examples:
+  # Table overview
+  autoversion branches
+
+  # Markdown table for a release checklist
+  autoversion branches -o markdown`,
+		Run: runBranches,
+	}
+	applyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Write the version into project files",
+		Long: `Writes the calculated version into the files configured in applyFiles.
+
+Supported file types: package.json (npm), pyproject.toml (PEP 440), Cargo.toml (semver),
+Chart.yaml (chart version and appVersion), *.csproj (.NET versions), pom.xml (Maven),
+VERSION files and regular expression replacements in any other file. Only the version
+values are changed, so formatting and comments are kept.
+
+Example configuration:
+  applyFiles:
+    - path: package.json
+    - path: src/*/*.csproj
+    - path: docs/conf.py
+      type: regex
+      pattern: release = "(.*)"
+      mode: pep440
+
+Examples:
+  # Show what would change
+  autoversion apply --dry-run
+
+  # Write the version
+  autoversion apply`,
+		Run: runApply,
+	}
+	tagCmd = &cobra.Command{
+		Use:   "tag",
+		Short: "Create the release tag for the current commit",
+		Long: `Creates an annotated tag for the calculated version on HEAD. The tag name is the
+configured tagPrefix followed by the semver version (e.g. v1.2.3).
+
+The worktree must be clean, HEAD must be on a main branch and the tag must not exist
+yet. --force skips these checks and moves an existing tag. Signed tags use the signing
+configuration of git (user.signingkey, gpg.format).
+
+Examples:
+  # Show the tag that would be created
+  autoversion tag --dry-run
+
+  # Create a signed tag and push it to origin
+  autoversion tag --sign --push
+
+  # Push to another remote
+  autoversion tag --push --remote upstream`,
+		Run: runTag,
+	}
+	bumpCmd = &cobra.Command{
+		Use:   "bump major|minor|patch|prerelease [label]",
+		Short: "Calculate the next major, minor, patch or prerelease version",
+		Long: `Increments the version of the most recent tag in the history of HEAD and prints it,
+or creates the tag with --tag. Without tags, the version is bumped from 0.0.0.
+
+  major, minor, patch   1.2.3 -> 2.0.0, 1.3.0, 1.2.4 (2.0.0-rc.2 major -> 2.0.0)
+  with a label          1.2.3 minor rc -> 1.3.0-rc.1
+  prerelease [label]    1.2.3 -> 1.2.4-rc.1, 1.3.0-rc.1 -> 1.3.0-rc.2, 1.3.0-beta.2 rc -> 1.3.0-rc.1
+
+A warning is printed when the new version isn't greater than every existing tag,
+including tags on other branches. --tag creates the tag like the tag command.
+
+Examples:
+  # Print the next minor version
+  autoversion bump minor
+
+  # Tag the first release candidate of the next major version and push it
+  autoversion bump major rc --tag --push`,
+		Args:      cobra.RangeArgs(1, 2),
+		ValidArgs: defaults.ValidBumpParts,
+		Run:       runBump,
+	}
+	changelogCmd = &cobra.Command{
+		Use:   "changelog",
+		Short: "Generate the changelog of a release",
+		Long: `Prints a markdown changelog of the commits since the previous tag, grouped by
+Conventional Commit type (feat, fix, perf, revert, docs, refactor). Breaking changes
+are listed first, commits that aren't Conventional Commits under "Other Changes".
+
+--to defaults to HEAD and --from to the most recent tag before it, so the changelog of
+a tagged commit lists the commits since the tag before. Pull request numbers ("(#123)")
+and commit SHAs are linked with changelogPullRequestUrl and changelogCommitUrl.
+
+--write prepends the changelog to CHANGELOG.md. A section for the same version is
+replaced, so writing it again doesn't add a duplicate.
+
+Examples:
+  # Changelog of the upcoming release
+  autoversion changelog
+
+  # Changelog of a past release
+  autoversion changelog --from v1.1.0 --to v1.2.0
+
+  # Update CHANGELOG.md
+  autoversion changelog --write`,
+		Run: runChangelog,
+	}
+	releaseNotesCmd = &cobra.Command{
+		Use:   "release-notes",
+		Short: "Print the release notes of a release",
+		Long: `Prints the release notes of the commits since the previous tag: the version, the
+previous version, the commits with their Conventional Commit type, scope and breaking
+flag, and the contributors. The commits and version are the same as those of the
+changelog command.
+
+Examples:
+  # Release notes of the upcoming release for a release bot
+  autoversion release-notes -o json
+
+  # Release notes of a past release
+  autoversion release-notes --to v1.2.0 -o json`,
+		Run: runReleaseNotes,
+	}
+	shouldReleaseCmd = &cobra.Command{
+		Use:   "should-release",
+		Short: "Exit 0 when the commits since the last tag should be released",
+		Long: `Exits with 0 when the commits since the last tag contain a releasable change, with 1
+when they don't and with 2 on errors. The reason is printed either way.
+
+A commit is releasable when its message doesn't match releaseSkipPatterns ([skip release]
+by default), it is a breaking change or its Conventional Commit type is in releaseTypes
+(feat, fix, perf, revert and commits that aren't Conventional Commits by default), and
+it changes a path matched by releasePaths (all paths by default).
+
+Example configuration:
+  releaseTypes: [feat, fix, perf]
+  releasePaths: ["src/**", "!**/*.md"]
+
+Examples:
+  # Only publish when there is something to release, and fail on errors
+  autoversion should-release || status=$?
+  case "${status:-0}" in 0) make publish ;; 1) ;; *) exit 1 ;; esac`,
+		Run: runShouldRelease,
+	}
+	goCmd = &cobra.Command{
+		Use:   "go",
+		Short: "Embed the version in Go programs",
+		Long:  `Generates linker flags or Go source that embed the calculated version in Go programs.`,
+	}
+	goLdflagsCmd = &cobra.Command{
+		Use:   "ldflags",
+		Short: "Print -X linker flags that set version variables",
+		Long: `Prints -X linker flags that set Go string variables to version fields.
+
+Each --var is importpath.name or importpath.name=field. Without a field, the variable
+name picks it: a field of the same name (main.Semver, main.Branch), or Version
+(semverWithPrefix), Commit and Revision (sha), ShortCommit (shortSha) and Date
+(commitTime, the commit date, so builds are reproducible).
+
+Examples:
+  go build -ldflags "$(autoversion go ldflags --var main.Version --var main.Commit)"
+
+  # Set a variable to a specific field
+  autoversion go ldflags --var github.com/org/app/internal/build.Label=prerelease`,
+		Run: runGoLdflags,
+	}
+	goGenerateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a Go file with version constants",
+		Long: `Generates a Go file that declares a constant for every version field
+(Semver, SemverWithPrefix, Major, Minor, Patch, IsRelease, Branch, SHA, ShortSHA,
+CommitTime, ...).
+
+The package defaults to $GOPACKAGE, which go generate sets. When run in a package
+directory, autoversion uses the repository root and its configuration.
+
+Examples:
+  //go:generate autoversion go generate --out version_gen.go
+
+  autoversion go generate --package version --out internal/version/version_gen.go`,
+		Run: runGoGenerate,
+	}
+	dockerTagsCmd = &cobra.Command{
+		Use:   "docker-tags",
+		Short: "Generate container image tags and OCI labels for the version",
+		Long: `Generates the image tags for the calculated version, one per line.
+
+Releases get the full version and the floating tags 1.2, 1 and latest (latest only
+on the main branch). Other versions get the version as a valid image tag ("+" is
+replaced, at most 128 characters) and a floating branch-<name> tag. Choose the
+floating tags with dockerFloatingTags.
+
+Use --output labels for the org.opencontainers.image.version and revision labels.
+
+Examples:
+  # Tags for an image
+  autoversion docker-tags --image ghcr.io/org/app
+
+  # Build with all tags and labels
+  docker build $(autoversion docker-tags -i app | sed 's/^/-t /') \
+    $(autoversion docker-tags -o labels | sed 's/^/--label /') .`,
+		Run: runDockerTags,
+	}
 	ghVersionsCmd = &cobra.Command{
 		Use:   "gh-versions",
 		Short: "Get calculated versions from GitHub Actions workflow runs",
@@ -82,9 +392,76 @@ func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is .autoversion.yaml)")
 	rootCmd.PersistentFlags().StringArrayVar(&configFlag, "config-flag", []string{}, "override config setting (format: key=value, can be used multiple times)")
+	rootCmd.Flags().StringVar(&refFlag, "ref", "", "calculate the version for a commit SHA, branch or tag instead of HEAD")
+	rootCmd.Flags().StringVar(&branchFlag, "branch", "", "branch name to version --ref as (default: derived from --ref)")
+	rootCmd.Flags().StringVar(&formatFlag, "format", "", "Go template to format the output with, e.g. '{{.Major}}.{{.Minor}}-{{.ShortSHA}}' (overrides outputTemplate and mode)")
+	rootCmd.Flags().StringVarP(&outputFlag, "output", "o", "", "print the JSON output fields as variable assignments: "+strings.Join(emit.ExportFormats, ", "))
+	rootCmd.Flags().StringVar(&emitFlag, "emit", "", "publish the version to the CI system: 'ci' (detect) or one of "+strings.Join(emit.Providers(), ", "))
 	rootCmd.AddCommand(schemaCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(ghVersionsCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(findCmd)
+	rootCmd.AddCommand(branchesCmd)
+	rootCmd.AddCommand(dockerTagsCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(bumpCmd)
+	rootCmd.AddCommand(changelogCmd)
+	rootCmd.AddCommand(releaseNotesCmd)
+	rootCmd.AddCommand(shouldReleaseCmd)
+	rootCmd.AddCommand(goCmd)
+	goCmd.AddCommand(goLdflagsCmd)
+	goCmd.AddCommand(goGenerateCmd)
+
+	// history command flags
+	historyCmd.Flags().StringVarP(&historyBranch, "branch", "b", "", "branch to list (default: current branch)")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "L", 0, "maximum number of commits to list (0 = all)")
+	historyCmd.Flags().StringVarP(&historyOutputFmt, "output", "o", "table", "output format: table, jsonl")
+	historyCmd.Flags().BoolVar(&historyCheck, "check", false, "exit with an error if versions go backwards or are duplicated")
+
+	// find command flags
+	findCmd.Flags().StringVarP(&findOutputFmt, "output", "o", "table", "output format: table, json")
+
+	// branches command flags
+	branchesCmd.Flags().StringVarP(&branchesOutputFmt, "output", "o", "table", "output format: table, json, markdown")
+
+	// schema command flags
+	schemaCmd.Flags().BoolVar(&schemaOutput, "output", false, "generate the schema of the JSON output instead of the configuration file")
+
+	// apply command flags
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "show a diff of the changes instead of writing the files")
+
+	// tag and bump command flags
+	bumpCmd.Flags().BoolVar(&bumpTag, "tag", false, "create the tag for the new version on HEAD")
+	for _, cmd := range []*cobra.Command{tagCmd, bumpCmd} {
+		cmd.Flags().StringVarP(&tagMessage, "message", "m", "", "tag message (default \"Release <version>\")")
+		cmd.Flags().BoolVarP(&tagSign, "sign", "s", false, "sign the tag")
+		cmd.Flags().BoolVar(&tagForce, "force", false, "tag a dirty worktree or a branch other than main, and move an existing tag")
+		cmd.Flags().BoolVar(&tagDryRun, "dry-run", false, "show the tag that would be created without creating it")
+		cmd.Flags().BoolVar(&tagPush, "push", false, "push the tag to a remote")
+		cmd.Flags().StringVar(&tagRemote, "remote", "", "remote to push to (default: the first configured remote)")
+	}
+
+	// changelog command flags
+	changelogCmd.Flags().StringVar(&changelogFrom, "from", "", "tag or commit to list the changes since (default: the most recent tag before --to)")
+	changelogCmd.Flags().StringVar(&changelogTo, "to", "", "tag or commit to list the changes up to (default: HEAD)")
+	changelogCmd.Flags().BoolVar(&changelogWrite, "write", false, "prepend the changelog to --file instead of printing it")
+	changelogCmd.Flags().StringVar(&changelogFile, "file", "CHANGELOG.md", "changelog file written by --write")
+
+	// release-notes command flags
+	releaseNotesCmd.Flags().StringVar(&releaseNotesFrom, "from", "", "tag or commit to list the changes since (default: the most recent tag before --to)")
+	releaseNotesCmd.Flags().StringVar(&releaseNotesTo, "to", "", "tag or commit to list the changes up to (default: HEAD)")
+	releaseNotesCmd.Flags().StringVarP(&releaseNotesOutputFmt, "output", "o", "markdown", "output format: markdown, json")
+
+	// go command flags
+	goLdflagsCmd.Flags().StringArrayVar(&goVars, "var", []string{}, "variable to set, as importpath.name or importpath.name=field (can be used multiple times)")
+	goGenerateCmd.Flags().StringVar(&goPackage, "package", "", "package name of the generated file (default $GOPACKAGE or main)")
+	goGenerateCmd.Flags().StringVar(&goOut, "out", "", "file to write (default stdout)")
+
+	// docker-tags command flags
+	dockerTagsCmd.Flags().StringArrayVarP(&dockerImages, "image", "i", []string{}, "image name to prefix the tags with, e.g. ghcr.io/org/app (can be used multiple times)")
+	dockerTagsCmd.Flags().StringVarP(&dockerOutputFmt, "output", "o", "tags", "output format: tags, labels, json")
 
 	// gh-versions command flags
 	ghVersionsCmd.Flags().StringVarP(&ghWorkflow, "workflow", "w", "", "workflow name or filename (e.g., 'CI' or 'ci.yml')")
@@ -132,6 +509,18 @@ func initConfig() {
 	viper.SetDefault("useCIBranch", defaults.DefaultUseCIBranch)
 	viper.SetDefault("failOnOutdatedBase", defaults.DefaultFailOnOutdated)
 	viper.SetDefault("outdatedBaseCheckMode", defaults.DefaultOutdatedCheckMode)
+	viper.SetDefault("mainBranchSource", defaults.DefaultMainBranchSource)
+	viper.SetDefault("failOnMainBranchDivergence", defaults.DefaultFailOnDivergence)
+	viper.SetDefault("githubActionsOutput", defaults.DefaultGitHubActionsOutput)
+	viper.SetDefault("variablePrefix", defaults.DefaultVariablePrefix)
+	viper.SetDefault("pep440BranchSegment", defaults.DefaultPep440BranchSegment)
+	viper.SetDefault("pep440LocalLabel", defaults.DefaultPep440LocalLabel)
+	viper.SetDefault("mavenSnapshotStyle", defaults.DefaultMavenSnapshotStyle)
+	viper.SetDefault("dotnetAssemblyVersion", defaults.DefaultDotnetAssemblyVersion)
+	viper.SetDefault("npmPrereleaseTag", defaults.DefaultNpmPrereleaseTag)
+	viper.SetDefault("dockerFloatingTags", defaults.DefaultDockerFloatingTags)
+	viper.SetDefault("releaseTypes", defaults.DefaultReleaseTypes)
+	viper.SetDefault("releaseSkipPatterns", defaults.DefaultReleaseSkipPatterns)
 
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
@@ -147,6 +536,19 @@ func initConfig() {
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
 
+		// Parse settings of a known type as that type, so e.g. packageRevision=1 stays a string
+		// and packageEpoch=1 a number
+		switch kind, _ := config.FieldKind(key); kind {
+		case reflect.String:
+			viper.Set(key, value)
+			continue
+		case reflect.Int:
+			if intVal, err := strconv.Atoi(value); err == nil {
+				viper.Set(key, intVal)
+				continue
+			}
+		}
+
 		// Try to parse as boolean
 		if boolVal, err := strconv.ParseBool(value); err == nil {
 			viper.Set(key, boolVal)
@@ -165,7 +567,88 @@ func initConfig() {
 }
 
 func run(cmd *cobra.Command, args []string) {
-	// Build config from viper settings
+	cfg := buildConfig()
+
+	if branchFlag != "" && refFlag == "" {
+		fmt.Fprintln(os.Stderr, "Error: --branch can only be used together with --ref")
+		os.Exit(1)
+	}
+	if formatFlag != "" {
+		cfg.OutputTemplate = &formatFlag
+	}
+	prefix, err := emit.VariablePrefix(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	result, err := version.CalculateResult(cfg, refFlag, branchFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	ver, err := version.FormatResult(result, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.DotnetPropsFile != nil && *cfg.DotnetPropsFile != "" {
+		versions, err := version.NewDotnetVersions(result, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := version.WriteDotnetProps(*cfg.DotnetPropsFile, versions); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.HelmChartFile != nil && *cfg.HelmChartFile != "" {
+		versions, err := version.NewHelmVersions(result, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := version.UpdateChartFile(*cfg.HelmChartFile, versions); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if outputFlag != "" {
+		exported, err := emit.Export(outputFlag, prefix, result)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(exported)
+	} else {
+		fmt.Println(ver)
+	}
+
+	// Publish the version natively to the CI system
+	if emitFlag != "" {
+		writer, err := emit.ForProvider(emitFlag, emit.Options{Stdout: os.Stdout, Prefix: prefix})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := writer.Write(ver, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Published version for %s\n", writer.Provider())
+	} else if gh, ok := emit.DetectGitHubActions(prefix); ok && (cfg.GitHubActionsOutput == nil || *cfg.GitHubActionsOutput) {
+		if err := gh.Write(ver, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "Wrote version to GitHub Actions outputs, environment and step summary")
+	}
+}
+
+// buildConfig builds the config from viper settings
+func buildConfig() *config.Config {
 	cfg := &config.Config{}
 
 	// Handle mainBranches (with backward compatibility for mainBranch)
@@ -220,16 +703,139 @@ func run(cmd *cobra.Command, args []string) {
 		cfg.OutdatedBaseCheckMode = &outdatedBaseCheckMode
 	}
 
-	ver, err := version.CalculateWithConfig(cfg)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	// Handle remotes (a single remote or an ordered list)
+	if viper.IsSet("remotes") {
+		cfg.Remotes = viper.GetStringSlice("remotes")
+	} else if viper.IsSet("remote") {
+		cfg.Remote = viper.GetString("remote")
+	}
+
+	if viper.IsSet("mainBranchSource") {
+		mainBranchSource := viper.GetString("mainBranchSource")
+		cfg.MainBranchSource = &mainBranchSource
+	}
+
+	if viper.IsSet("failOnMainBranchDivergence") {
+		failOnMainBranchDivergence := viper.GetBool("failOnMainBranchDivergence")
+		cfg.FailOnMainBranchDivergence = &failOnMainBranchDivergence
+	}
+
+	if viper.IsSet("outputTemplate") {
+		outputTemplate := viper.GetString("outputTemplate")
+		cfg.OutputTemplate = &outputTemplate
+	}
+
+	if viper.IsSet("githubActionsOutput") {
+		githubActionsOutput := viper.GetBool("githubActionsOutput")
+		cfg.GitHubActionsOutput = &githubActionsOutput
+	}
+
+	if viper.IsSet("variablePrefix") {
+		variablePrefix := viper.GetString("variablePrefix")
+		cfg.VariablePrefix = &variablePrefix
+	}
+
+	if viper.IsSet("pep440BranchSegment") {
+		pep440BranchSegment := viper.GetString("pep440BranchSegment")
+		cfg.Pep440BranchSegment = &pep440BranchSegment
+	}
+
+	if viper.IsSet("pep440Labels") {
+		cfg.Pep440Labels = viper.GetStringMapString("pep440Labels")
+	}
+
+	if viper.IsSet("pep440LocalLabel") {
+		pep440LocalLabel := viper.GetBool("pep440LocalLabel")
+		cfg.Pep440LocalLabel = &pep440LocalLabel
+	}
+
+	if viper.IsSet("mavenSnapshotStyle") {
+		mavenSnapshotStyle := viper.GetString("mavenSnapshotStyle")
+		cfg.MavenSnapshotStyle = &mavenSnapshotStyle
+	}
+
+	if viper.IsSet("dotnetAssemblyVersion") {
+		dotnetAssemblyVersion := viper.GetString("dotnetAssemblyVersion")
+		cfg.DotnetAssemblyVersion = &dotnetAssemblyVersion
+	}
+
+	if viper.IsSet("dotnetPropsFile") {
+		dotnetPropsFile := viper.GetString("dotnetPropsFile")
+		cfg.DotnetPropsFile = &dotnetPropsFile
+	}
+
+	if viper.IsSet("packageEpoch") {
+		packageEpoch := viper.GetInt("packageEpoch")
+		cfg.PackageEpoch = &packageEpoch
+	}
+
+	if viper.IsSet("packageRevision") {
+		packageRevision := viper.GetString("packageRevision")
+		cfg.PackageRevision = &packageRevision
+	}
+
+	if viper.IsSet("npmPrereleaseTag") {
+		npmPrereleaseTag := viper.GetString("npmPrereleaseTag")
+		cfg.NpmPrereleaseTag = &npmPrereleaseTag
+	}
+
+	if viper.IsSet("helmChartTagPrefix") {
+		helmChartTagPrefix := viper.GetString("helmChartTagPrefix")
+		cfg.HelmChartTagPrefix = &helmChartTagPrefix
+	}
+
+	if viper.IsSet("helmAppVersionTagPrefix") {
+		helmAppVersionTagPrefix := viper.GetString("helmAppVersionTagPrefix")
+		cfg.HelmAppVersionTagPrefix = &helmAppVersionTagPrefix
+	}
+
+	if viper.IsSet("helmChartFile") {
+		helmChartFile := viper.GetString("helmChartFile")
+		cfg.HelmChartFile = &helmChartFile
+	}
+
+	if viper.IsSet("applyFiles") {
+		if err := viper.UnmarshalKey("applyFiles", &cfg.ApplyFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid applyFiles: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if viper.IsSet("dockerFloatingTags") {
+		cfg.DockerFloatingTags = viper.GetStringSlice("dockerFloatingTags")
+	}
+
+	if viper.IsSet("changelogCommitUrl") {
+		changelogCommitURL := viper.GetString("changelogCommitUrl")
+		cfg.ChangelogCommitURL = &changelogCommitURL
+	}
+
+	if viper.IsSet("releaseTypes") {
+		cfg.ReleaseTypes = viper.GetStringSlice("releaseTypes")
 	}
-	fmt.Println(ver)
+
+	if viper.IsSet("releasePaths") {
+		cfg.ReleasePaths = viper.GetStringSlice("releasePaths")
+	}
+
+	if viper.IsSet("releaseSkipPatterns") {
+		cfg.ReleaseSkipPatterns = viper.GetStringSlice("releaseSkipPatterns")
+	}
+
+	if viper.IsSet("changelogPullRequestUrl") {
+		changelogPullRequestURL := viper.GetString("changelogPullRequestUrl")
+		cfg.ChangelogPullRequestURL = &changelogPullRequestURL
+	}
+
+	return cfg
 }
 
 func runSchema(cmd *cobra.Command, args []string) {
-	schema, err := config.GenerateSchema()
+	generate := config.GenerateSchema
+	if schemaOutput {
+		generate = version.GenerateOutputSchema
+	}
+	schema, err := generate()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating schema: %v\n", err)
 		os.Exit(1)
@@ -237,6 +843,495 @@ func runSchema(cmd *cobra.Command, args []string) {
 	fmt.Println(schema)
 }
 
+func runApply(cmd *cobra.Command, args []string) {
+	cfg := buildConfig()
+	result, err := version.CalculateResult(cfg, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	changes, err := apply.Plan(cfg.ApplyFiles, result, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if applyDryRun {
+		for _, change := range changes {
+			if !change.Changed() {
+				fmt.Fprintf(os.Stderr, "%s is already at %s\n", change.Path, change.Version)
+				continue
+			}
+			fmt.Print(apply.Diff(change))
+		}
+		return
+	}
+
+	if err := apply.Write(changes); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, change := range changes {
+		if change.Changed() {
+			fmt.Printf("Updated %s to %s\n", change.Path, change.Version)
+		} else {
+			fmt.Printf("%s is already at %s\n", change.Path, change.Version)
+		}
+	}
+}
+
+func runTag(cmd *cobra.Command, args []string) {
+	result, err := version.Tag(buildConfig(), tagOptions())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	printTagResult(result)
+}
+
+func runBump(cmd *cobra.Command, args []string) {
+	label := ""
+	if len(args) > 1 {
+		label = args[1]
+	}
+	var opts *version.TagOptions
+	if bumpTag {
+		tagOpts := tagOptions()
+		opts = &tagOpts
+	}
+	result, err := version.Bump(buildConfig(), args[0], label, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if result.Warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", result.Warning)
+	}
+	if result.Tag != nil {
+		printTagResult(result.Tag)
+		return
+	}
+	fmt.Println(result.Version)
+}
+
+// tagOptions returns the tag options given by the tag and bump command flags
+func tagOptions() version.TagOptions {
+	return version.TagOptions{
+		Message: tagMessage,
+		Sign:    tagSign,
+		Force:   tagForce,
+		DryRun:  tagDryRun,
+		Push:    tagPush || tagRemote != "",
+		Remote:  tagRemote,
+	}
+}
+
+// printTagResult prints the tag created by the tag and bump commands
+func printTagResult(result *version.TagResult) {
+	if !result.Created {
+		fmt.Printf("Would create tag %s on %s\n", result.Name, result.SHA)
+		if result.Remote != "" {
+			fmt.Printf("Would push tag %s to %s\n", result.Name, result.Remote)
+		}
+		return
+	}
+	fmt.Println(result.Name)
+	if result.Remote != "" {
+		fmt.Fprintf(os.Stderr, "Pushed tag %s to %s\n", result.Name, result.Remote)
+	}
+}
+
+func runChangelog(cmd *cobra.Command, args []string) {
+	cfg := buildConfig()
+	changes, err := version.Changes(cfg, changelogFrom, changelogTo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	section, err := changelog.Markdown(changes, changelogOptions(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !changelogWrite {
+		fmt.Print(section)
+		return
+	}
+
+	existing, err := os.ReadFile(changelogFile)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	updated := changelog.Prepend(string(existing), section, changes.Version)
+	if updated == string(existing) {
+		fmt.Printf("%s is up to date for %s\n", changelogFile, changes.Version)
+		return
+	}
+	if err := os.WriteFile(changelogFile, []byte(updated), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Updated %s for %s\n", changelogFile, changes.Version)
+}
+
+func runReleaseNotes(cmd *cobra.Command, args []string) {
+	if releaseNotesOutputFmt != "markdown" && releaseNotesOutputFmt != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid output format '%s': must be one of markdown, json\n", releaseNotesOutputFmt)
+		os.Exit(1)
+	}
+	cfg := buildConfig()
+	changes, err := version.Changes(cfg, releaseNotesFrom, releaseNotesTo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch releaseNotesOutputFmt {
+	case "json":
+		output, err := json.MarshalIndent(changelog.NewReleaseNotes(changes), "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+	default:
+		section, err := changelog.Markdown(changes, changelogOptions(cfg))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(section)
+	}
+}
+
+// should-release exit codes. Errors get their own code, so a pipeline that skips publishing when
+// there is nothing to release doesn't silently skip it when autoversion fails.
+const (
+	shouldReleaseExitRelease   = 0
+	shouldReleaseExitNoRelease = 1
+	shouldReleaseExitError     = 2
+)
+
+func runShouldRelease(cmd *cobra.Command, args []string) {
+	code, message := shouldRelease(buildConfig())
+	if code == shouldReleaseExitError {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", message)
+	} else {
+		fmt.Println(message)
+	}
+	os.Exit(code)
+}
+
+// shouldRelease decides whether the commits since the last tag should be released and returns
+// the exit code and the message to print
+func shouldRelease(cfg *config.Config) (int, string) {
+	rules, err := changelog.NewReleaseRules(cfg)
+	if err != nil {
+		return shouldReleaseExitError, err.Error()
+	}
+	changes, err := version.Changes(cfg, "", "")
+	if err != nil {
+		return shouldReleaseExitError, err.Error()
+	}
+	decision, err := changelog.ShouldRelease(changes, rules, changes.ChangedFiles)
+	if err != nil {
+		return shouldReleaseExitError, err.Error()
+	}
+	if !decision.Release {
+		return shouldReleaseExitNoRelease, fmt.Sprintf("No release: %s", decision.Reason)
+	}
+	return shouldReleaseExitRelease, fmt.Sprintf("Release %s: %s", changes.Version, decision.Reason)
+}
+
+// changelogOptions returns the changelog link templates of the configuration
+func changelogOptions(cfg *config.Config) changelog.Options {
+	opts := changelog.Options{}
+	if cfg.ChangelogCommitURL != nil {
+		opts.CommitURL = *cfg.ChangelogCommitURL
+	}
+	if cfg.ChangelogPullRequestURL != nil {
+		opts.PullRequestURL = *cfg.ChangelogPullRequestURL
+	}
+	return opts
+}
+
+func runGoLdflags(cmd *cobra.Command, args []string) {
+	result, err := version.CalculateResult(buildConfig(), "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	ldflags, err := emit.GoLdflags(goVars, result)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(ldflags)
+}
+
+func runGoGenerate(cmd *cobra.Command, args []string) {
+	pkg := goPackage
+	if pkg == "" {
+		pkg = os.Getenv("GOPACKAGE")
+	}
+	if pkg == "" {
+		pkg = "main"
+	}
+	out := goOut
+	if out != "" {
+		absOut, err := filepath.Abs(out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		out = absOut
+	}
+
+	// go generate runs in the package directory
+	if err := chdirToRepoRoot(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	result, err := version.CalculateResult(buildConfig(), "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	source, err := emit.GoSource(pkg, result)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if out == "" {
+		fmt.Print(source)
+		return
+	}
+	if err := os.WriteFile(out, []byte(source), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write %s: %v\n", out, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", out)
+}
+
+// chdirToRepoRoot changes to the root of the git repository that contains the current directory,
+// and loads the configuration from there. Nothing changes when already at the root.
+func chdirToRepoRoot() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root := dir
+	for {
+		if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			// Not in a repository, which fails with the usual error later
+			return nil
+		}
+		root = parent
+	}
+	if root == dir {
+		return nil
+	}
+
+	if cfgFile != "" {
+		absCfgFile, err := filepath.Abs(cfgFile)
+		if err != nil {
+			return err
+		}
+		cfgFile = absCfgFile
+	}
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("failed to change to repository root %s: %w", root, err)
+	}
+	initConfig()
+	return nil
+}
+
+func runDockerTags(cmd *cobra.Command, args []string) {
+	cfg := buildConfig()
+	result, err := version.CalculateResult(cfg, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	metadata, err := version.NewDockerMetadata(result, cfg, dockerImages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch dockerOutputFmt {
+	case "json":
+		output, err := json.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+	case "labels":
+		names := make([]string, 0, len(metadata.Labels))
+		for name := range metadata.Labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s=%s\n", name, metadata.Labels[name])
+		}
+	case "tags":
+		for _, tag := range metadata.Tags {
+			fmt.Println(tag)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid output format '%s': must be one of tags, labels, json\n", dockerOutputFmt)
+		os.Exit(1)
+	}
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	if historyOutputFmt != "table" && historyOutputFmt != "jsonl" {
+		fmt.Fprintf(os.Stderr, "Error: invalid output format '%s': must be one of table, jsonl\n", historyOutputFmt)
+		os.Exit(1)
+	}
+	entries, err := version.History(buildConfig(), historyBranch, historyLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch historyOutputFmt {
+	case "jsonl":
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMMIT\tDATE\tVERSION\tSUBJECT")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.SHA[:7], e.Date.Format("2006-01-02"), e.Version, e.Subject)
+		}
+		w.Flush()
+	}
+
+	if historyCheck {
+		problems := version.AuditHistory(entries)
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "Problem: %s\n", problem)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "No problems found: versions always increase and are unique")
+	}
+}
+
+func runFind(cmd *cobra.Command, args []string) {
+	if findOutputFmt != "table" && findOutputFmt != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid output format '%s': must be one of table, json\n", findOutputFmt)
+		os.Exit(1)
+	}
+	results, err := version.Find(buildConfig(), args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "No commit produces version %s\n", args[0])
+		os.Exit(1)
+	}
+	if len(results) > 1 {
+		fmt.Fprintf(os.Stderr, "Warning: %d commits produce version %s\n", len(results), args[0])
+	}
+
+	switch findOutputFmt {
+	case "json":
+		output, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMMIT\tBRANCH\tBASE TAG\tDATE\tSUBJECT")
+		for _, r := range results {
+			baseTag := r.BaseTag
+			if baseTag == "" {
+				baseTag = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.SHA, r.Branch, baseTag, r.Date.Format("2006-01-02"), r.Subject)
+		}
+		w.Flush()
+	}
+}
+
+func runBranches(cmd *cobra.Command, args []string) {
+	if branchesOutputFmt != "table" && branchesOutputFmt != "json" && branchesOutputFmt != "markdown" {
+		fmt.Fprintf(os.Stderr, "Error: invalid output format '%s': must be one of table, json, markdown\n", branchesOutputFmt)
+		os.Exit(1)
+	}
+	statuses, err := version.Branches(buildConfig())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch branchesOutputFmt {
+	case "json":
+		output, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+	case "markdown":
+		fmt.Println("| Branch | Version | Ahead | Behind main | Outdated base |")
+		fmt.Println("|--------|---------|-------|-------------|---------------|")
+		for _, s := range statuses {
+			fmt.Printf("| `%s` | %s | %d | %d | %s |\n", s.Ref, branchVersionOrError(s), s.Ahead, s.Behind, outdatedDescription(s))
+		}
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BRANCH\tCOMMIT\tVERSION\tAHEAD\tBEHIND\tOUTDATED")
+		for _, s := range statuses {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", s.Ref, s.SHA[:7], branchVersionOrError(s), s.Ahead, s.Behind, outdatedDescription(s))
+		}
+		w.Flush()
+	}
+}
+
+// branchVersionOrError returns the branch version, or the error that prevented calculating it
+func branchVersionOrError(s version.BranchStatus) string {
+	if s.Version == "" && s.Error != "" {
+		return "error: " + s.Error
+	}
+	return s.Version
+}
+
+// outdatedDescription describes whether a branch's base is outdated
+func outdatedDescription(s version.BranchStatus) string {
+	switch {
+	case s.IsMainBranch:
+		return "-"
+	case s.Outdated && s.NewTagOnMain != "":
+		return "yes (new tag " + s.NewTagOnMain + ")"
+	case s.Outdated:
+		return "yes"
+	default:
+		return "no"
+	}
+}
+
 func runGhVersions(cmd *cobra.Command, args []string) {
 	versions, err := ghactions.GetVersionsFromRuns(ghWorkflow, ghJob, ghStep, ghLimit, ghVerbose)
 	if err != nil {
@@ -257,8 +1352,7 @@ func runGhVersions(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 		fmt.Println(string(output))
-	default:
-		// Table format
+	case "table":
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 		fmt.Fprintln(w, "BRANCH\tCOMMIT\tVERSION\tWORKFLOW\tJOB\tSTATUS\tRUN")
 		for _, v := range versions {
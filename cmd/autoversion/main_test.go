@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\nOutput: %s", args, err, output)
+	}
+}
+
+func TestShouldReleaseExitCodes(t *testing.T) {
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-b", "main")
+	runGit(t, repo, "config", "user.email", "test@example.com")
+	runGit(t, repo, "config", "user.name", "Test User")
+	runGit(t, repo, "config", "commit.gpgsign", "false")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial commit")
+	runGit(t, repo, "tag", "v1.0.0")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	tagPrefix := "v"
+	cfg := &config.Config{MainBranch: "main", TagPrefix: &tagPrefix}
+
+	// Nothing to release is not an error
+	if code, message := shouldRelease(cfg); code != shouldReleaseExitNoRelease || !strings.HasPrefix(message, "No release:") {
+		t.Errorf("Expected exit code %d for a tagged HEAD, got %d: %s", shouldReleaseExitNoRelease, code, message)
+	}
+
+	runGit(t, repo, "commit", "--allow-empty", "-m", "fix: handle empty tags")
+	if code, message := shouldRelease(cfg); code != shouldReleaseExitRelease || !strings.HasPrefix(message, "Release 1.0.1:") {
+		t.Errorf("Expected exit code %d for a fix, got %d: %s", shouldReleaseExitRelease, code, message)
+	}
+
+	// Errors must not look like "nothing to release"
+	invalid := &config.Config{MainBranch: "main", TagPrefix: &tagPrefix, ReleaseSkipPatterns: []string{"[skip"}}
+	if code, message := shouldRelease(invalid); code != shouldReleaseExitError {
+		t.Errorf("Expected exit code %d for an invalid skip pattern, got %d: %s", shouldReleaseExitError, code, message)
+	}
+	missingMain := &config.Config{MainBranch: "trunk", TagPrefix: &tagPrefix}
+	if code, message := shouldRelease(missingMain); code != shouldReleaseExitError {
+		t.Errorf("Expected exit code %d for a missing main branch, got %d: %s", shouldReleaseExitError, code, message)
+	}
+}
@@ -9,7 +9,8 @@ CONFIG_FILE="$1"
 FAIL_ON_ERROR="${2:-true}"
 
 # Build autoversion command
-CMD="autoversion"
+# The action always writes its outputs, whatever githubActionsOutput is set to
+CMD="autoversion --emit github-actions"
 if [ -n "$CONFIG_FILE" ]; then
   CMD="$CMD --config $CONFIG_FILE"
 fi
@@ -26,31 +27,8 @@ if $CMD > "$TEMP_OUTPUT" 2> "$TEMP_ERROR"; then
 
   echo "Calculated version: $VERSION"
 
-  # Parse version components
-  # Format can be: 1.0.0 or 1.0.0-pre.0 or 1.0.0-feature.1
-
-  # Extract base version (before any -)
-  BASE_VERSION="${VERSION%%-*}"
-
-  # Split major.minor.patch
-  IFS='.' read -r MAJOR MINOR PATCH <<< "$BASE_VERSION"
-
-  # Check if there's a prerelease part
-  if [[ "$VERSION" == *"-"* ]]; then
-    PRERELEASE="${VERSION#*-}"
-    IS_PRERELEASE="true"
-  else
-    PRERELEASE=""
-    IS_PRERELEASE="false"
-  fi
-
-  # Set GitHub Action outputs
-  echo "version=$VERSION" >> "$GITHUB_OUTPUT"
-  echo "major=$MAJOR" >> "$GITHUB_OUTPUT"
-  echo "minor=$MINOR" >> "$GITHUB_OUTPUT"
-  echo "patch=$PATCH" >> "$GITHUB_OUTPUT"
-  echo "prerelease=$PRERELEASE" >> "$GITHUB_OUTPUT"
-  echo "is-prerelease=$IS_PRERELEASE" >> "$GITHUB_OUTPUT"
+  # autoversion writes the step outputs ($GITHUB_OUTPUT), environment variables ($GITHUB_ENV)
+  # and step summary itself
 
   # Clean up
   rm -f "$TEMP_OUTPUT" "$TEMP_ERROR"
@@ -0,0 +1,134 @@
+// Package apply writes the calculated version into project files such as package.json, pom.xml
+// and Chart.yaml, changing only the version values so formatting and comments are kept
+package apply
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// Change is the new content of a file
+type Change struct {
+	Path    string
+	Type    string
+	Version string // version written to the file
+	Old     string // content before the change, empty for new files
+	New     string
+}
+
+// Changed reports whether the change modifies the file
+func (c Change) Changed() bool {
+	return c.Old != c.New
+}
+
+// Plan calculates the new content of every configured file without writing anything
+// Paths may be glob patterns. Missing files are an error, except for text files, which are created.
+func Plan(files []config.ApplyFile, result *version.Result, cfg *config.Config) ([]Change, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to apply the version to: configure applyFiles")
+	}
+
+	var changes []Change
+	for _, file := range files {
+		if file.Path == "" {
+			return nil, fmt.Errorf("applyFiles entry without a path")
+		}
+		paths, err := filepath.Glob(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid applyFiles path '%s': %w", file.Path, err)
+		}
+		if len(paths) == 0 {
+			fileType, err := fileType(file)
+			if err != nil {
+				return nil, err
+			}
+			if fileType != defaults.ApplyTypeText || strings.ContainsAny(file.Path, "*?[") {
+				return nil, fmt.Errorf("no files match '%s'", file.Path)
+			}
+			paths = []string{file.Path}
+		}
+
+		for _, path := range paths {
+			change, err := planFile(file, path, result, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// Write writes the changed files, keeping their permissions
+func Write(changes []Change) error {
+	for _, change := range changes {
+		if !change.Changed() {
+			continue
+		}
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(change.Path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(change.Path, []byte(change.New), mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", change.Path, err)
+		}
+	}
+	return nil
+}
+
+// planFile calculates the new content of a single file
+func planFile(file config.ApplyFile, path string, result *version.Result, cfg *config.Config) (Change, error) {
+	fileType, err := fileType(config.ApplyFile{Path: path, Type: file.Type})
+	if err != nil {
+		return Change{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !(os.IsNotExist(err) && fileType == defaults.ApplyTypeText) {
+		return Change{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	change := Change{Path: path, Type: fileType, Old: string(content)}
+	change.New, change.Version, err = update(fileType, file, change.Old, result, cfg)
+	if err != nil {
+		return Change{}, err
+	}
+	return change, nil
+}
+
+// fileType returns the configured type of a file, or detects it from the file name
+func fileType(file config.ApplyFile) (string, error) {
+	if file.Type != "" {
+		for _, valid := range defaults.ValidApplyTypes {
+			if file.Type == valid {
+				return file.Type, nil
+			}
+		}
+		return "", fmt.Errorf("invalid applyFiles type '%s': must be one of %v", file.Type, defaults.ValidApplyTypes)
+	}
+
+	name := filepath.Base(file.Path)
+	switch {
+	case name == "package.json":
+		return defaults.ApplyTypeNpm, nil
+	case name == "pyproject.toml":
+		return defaults.ApplyTypePyproject, nil
+	case name == "Cargo.toml":
+		return defaults.ApplyTypeCargo, nil
+	case name == "Chart.yaml":
+		return defaults.ApplyTypeHelm, nil
+	case strings.HasSuffix(name, ".csproj"), strings.HasSuffix(name, ".fsproj"), strings.HasSuffix(name, ".vbproj"):
+		return defaults.ApplyTypeCsproj, nil
+	case name == "pom.xml":
+		return defaults.ApplyTypePom, nil
+	case strings.EqualFold(strings.TrimSuffix(name, filepath.Ext(name)), "VERSION"):
+		return defaults.ApplyTypeText, nil
+	}
+	return "", fmt.Errorf("cannot detect the file type of '%s': set type to one of %v", file.Path, defaults.ValidApplyTypes)
+}
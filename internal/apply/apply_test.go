@@ -0,0 +1,323 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// testResult is a feature branch version
+var testResult = &version.Result{
+	Semver:          "1.2.4-login-x.3",
+	Pep440:          "1.2.4a3",
+	Major:           1,
+	Minor:           2,
+	Patch:           4,
+	Prerelease:      "login-x",
+	Build:           3,
+	Branch:          "feature/login-x",
+	SanitizedBranch: "login-x",
+	SHA:             "0123456789abcdef0123456789abcdef01234567",
+	BaseVersion:     "1.2.3",
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     config.ApplyFile
+		content  string
+		expected string
+	}{
+		{
+			name: "package.json",
+			file: config.ApplyFile{Path: "package.json"},
+			content: `{
+  "name": "app",
+  "description": "the \"version\" field",
+  "dependencies": {"version": "^1.0.0"},
+  "version":"0.0.0"
+}
+`,
+			expected: `{
+  "name": "app",
+  "description": "the \"version\" field",
+  "dependencies": {"version": "^1.0.0"},
+  "version":"1.2.4-login-x.3"
+}
+`,
+		},
+		{
+			name: "pyproject.toml",
+			file: config.ApplyFile{Path: "pyproject.toml"},
+			content: `[tool.black]
+version = "ignored"
+
+[project]
+name = "app"
+version = "0.0.0"  # set by autoversion
+`,
+			expected: `[tool.black]
+version = "ignored"
+
+[project]
+name = "app"
+version = "1.2.4a3"  # set by autoversion
+`,
+		},
+		{
+			name:     "poetry",
+			file:     config.ApplyFile{Path: "pyproject.toml"},
+			content:  "[tool.poetry]\nname = \"app\"\nversion = '0.1.0'\n",
+			expected: "[tool.poetry]\nname = \"app\"\nversion = '1.2.4a3'\n",
+		},
+		{
+			name: "Cargo.toml",
+			file: config.ApplyFile{Path: "Cargo.toml"},
+			content: `[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+serde = { version = "1.0" }
+`,
+			expected: `[package]
+name = "app"
+version = "1.2.4-login-x.3"
+
+[dependencies]
+serde = { version = "1.0" }
+`,
+		},
+		{
+			name:     "Chart.yaml",
+			file:     config.ApplyFile{Path: "Chart.yaml"},
+			content:  "apiVersion: v2\nversion: 0.1.0 # chart\nappVersion: \"0.1.0\"\n",
+			expected: "apiVersion: v2\nversion: 1.2.4-login-x.3 # chart\nappVersion: \"1.2.4-login-x.3\"\n",
+		},
+		{
+			name: "csproj",
+			file: config.ApplyFile{Path: "App.csproj"},
+			content: `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <!-- Set by autoversion -->
+    <Version>0.0.0</Version>
+    <FileVersion>0.0.0.0</FileVersion>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" Version="13.0.1" />
+  </ItemGroup>
+</Project>
+`,
+			expected: `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <!-- Set by autoversion -->
+    <Version>1.2.4-login-x0003</Version>
+    <FileVersion>1.2.4.3</FileVersion>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" Version="13.0.1" />
+  </ItemGroup>
+</Project>
+`,
+		},
+		{
+			name: "pom.xml",
+			file: config.ApplyFile{Path: "pom.xml"},
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <parent>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <version>0.0.1-SNAPSHOT</version>
+  <dependencies>
+    <dependency><version>1.0</version></dependency>
+  </dependencies>
+</project>
+`,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <parent>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <version>1.2.4-login-x-SNAPSHOT</version>
+  <dependencies>
+    <dependency><version>1.0</version></dependency>
+  </dependencies>
+</project>
+`,
+		},
+		{
+			name:     "VERSION",
+			file:     config.ApplyFile{Path: "VERSION"},
+			content:  "0.0.0\r\n",
+			expected: "1.2.4-login-x.3\r\n",
+		},
+		{
+			name:     "VERSION with mode",
+			file:     config.ApplyFile{Path: "VERSION", Mode: "deb"},
+			content:  "",
+			expected: "1.2.4~login.x.3\n",
+		},
+		{
+			name:     "regex",
+			file:     config.ApplyFile{Path: "conf.py", Type: "regex", Pattern: `release = "(.*)"`, Mode: "pep440"},
+			content:  "project = \"app\"\nrelease = \"0.1\"\n",
+			expected: "project = \"app\"\nrelease = \"1.2.4a3\"\n",
+		},
+		{
+			name:     "regex with named group",
+			file:     config.ApplyFile{Path: "main.go", Type: "regex", Pattern: `(const )?version = "(?P<version>[^"]*)"`},
+			content:  "package main\n\nconst version = \"dev\"\n",
+			expected: "package main\n\nconst version = \"1.2.4-login-x.3\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileType, err := fileType(tt.file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			updated, _, err := update(fileType, tt.file, tt.content, testResult, &config.Config{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if updated != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, updated)
+			}
+		})
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    config.ApplyFile
+		content string
+	}{
+		{"package.json without version", config.ApplyFile{Path: "package.json"}, `{"name": "app", "dependencies": {"version": "1"}}`},
+		{"pyproject.toml with dynamic version", config.ApplyFile{Path: "pyproject.toml"}, "[project]\ndynamic = [\"version\"]\n"},
+		{"pom.xml with inherited version", config.ApplyFile{Path: "pom.xml"}, "<project><parent><version>1.0</version></parent></project>"},
+		{"pom.xml with property", config.ApplyFile{Path: "pom.xml"}, "<project><version>${revision}</version></project>"},
+		{"csproj without version", config.ApplyFile{Path: "App.csproj"}, "<Project><PropertyGroup /></Project>"},
+		{"csproj with mode", config.ApplyFile{Path: "App.csproj", Mode: "semver"}, "<Project><PropertyGroup><Version>1.0</Version></PropertyGroup></Project>"},
+		{"regex without match", config.ApplyFile{Path: "a.txt", Type: "regex", Pattern: `v(\d+)`}, "no version here"},
+		{"regex without group", config.ApplyFile{Path: "a.txt", Type: "regex", Pattern: `v\d+`}, "v1"},
+		{"json mode", config.ApplyFile{Path: "VERSION", Mode: "json"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileType, err := fileType(tt.file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, _, err := update(fileType, tt.file, tt.content, testResult, &config.Config{}); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestFileType(t *testing.T) {
+	tests := map[string]string{
+		"web/package.json":    "npm",
+		"pyproject.toml":      "pyproject",
+		"crates/a/Cargo.toml": "cargo",
+		"charts/a/Chart.yaml": "helm",
+		"src/App.csproj":      "csproj",
+		"src/App.fsproj":      "csproj",
+		"pom.xml":             "pom",
+		"VERSION":             "text",
+		"version.txt":         "text",
+	}
+	for path, expected := range tests {
+		fileType, err := fileType(config.ApplyFile{Path: path})
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", path, err)
+		} else if fileType != expected {
+			t.Errorf("Expected type %s for %s, got %s", expected, path, fileType)
+		}
+	}
+
+	if _, err := fileType(config.ApplyFile{Path: "setup.cfg"}); err == nil {
+		t.Errorf("Expected error for a file type that can't be detected")
+	}
+	if _, err := fileType(config.ApplyFile{Path: "setup.cfg", Type: "ini"}); err == nil {
+		t.Errorf("Expected error for an invalid type")
+	}
+}
+
+func TestPlanAndWrite(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "package.json"), []byte(`{"version": "0.0.0"}`), 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	files := []config.ApplyFile{
+		{Path: filepath.Join(dir, "*", "package.json")},
+		{Path: filepath.Join(dir, "VERSION")},
+	}
+	changes, err := Plan(files, testResult, &config.Config{})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %d", len(changes))
+	}
+	// Nothing is written until Write
+	if _, err := os.Stat(filepath.Join(dir, "VERSION")); !os.IsNotExist(err) {
+		t.Errorf("Expected VERSION not to exist before Write")
+	}
+
+	if err := Write(changes); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "b", "package.json"))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != `{"version": "1.2.4-login-x.3"}` {
+		t.Errorf("Unexpected package.json: %s", content)
+	}
+	info, err := os.Stat(filepath.Join(dir, "b", "package.json"))
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions 0600 to be kept, got %o", info.Mode().Perm())
+	}
+	content, err = os.ReadFile(filepath.Join(dir, "VERSION"))
+	if err != nil {
+		t.Fatalf("Failed to read VERSION: %v", err)
+	}
+	if string(content) != "1.2.4-login-x.3\n" {
+		t.Errorf("Unexpected VERSION: %q", content)
+	}
+
+	// Applying again changes nothing
+	changes, err = Plan(files, testResult, &config.Config{})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	for _, change := range changes {
+		if change.Changed() {
+			t.Errorf("Expected %s to be unchanged", change.Path)
+		}
+	}
+
+	if _, err := Plan([]config.ApplyFile{{Path: filepath.Join(dir, "missing", "package.json")}}, testResult, &config.Config{}); err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Errorf("Expected an error for a missing file, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package apply
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "unchanged",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "changed line with context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			expected: `--- a/f
++++ b/f
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name: "separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: `--- a/f
++++ b/f
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -7,4 +7,4 @@
+ 7
+ 8
+ 9
+-10
++ten
+`,
+		},
+		{
+			name: "added line",
+			old:  "version: 1\n",
+			new:  "version: 2\nappVersion: 2\n",
+			expected: `--- a/f
++++ b/f
+@@ -1,1 +1,2 @@
+-version: 1
++version: 2
++appVersion: 2
+`,
+		},
+		{
+			name: "new file",
+			old:  "",
+			new:  "1.2.3\n",
+			expected: `--- a/f
++++ b/f
+@@ -0,0 +1,1 @@
++1.2.3
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Diff(Change{Path: "f", Old: tt.old, New: tt.new})
+			if diff != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package apply
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// tomlTableHeader matches a TOML table or array of tables header, e.g. [package] or [[bin]]
+var tomlTableHeader = regexp.MustCompile(`^\s*(\[\[?)\s*([^\[\]]+?)\s*\]\]?\s*(#.*)?$`)
+
+// tomlVersionLine matches a version key with a basic or literal string value
+var tomlVersionLine = regexp.MustCompile(`^(\s*version\s*=\s*)("[^"]*"|'[^']*')(.*)$`)
+
+// update returns the content of a file with the version written to it, and the version written
+func update(fileType string, file config.ApplyFile, content string, result *version.Result, cfg *config.Config) (string, string, error) {
+	if file.Mode != "" && (fileType == defaults.ApplyTypeHelm || fileType == defaults.ApplyTypeCsproj) {
+		return "", "", fmt.Errorf("mode can't be set for type '%s', which writes several versions", fileType)
+	}
+
+	switch fileType {
+	case defaults.ApplyTypeNpm:
+		v, err := fileVersion(file, result, cfg, "")
+		if err != nil {
+			return "", "", err
+		}
+		updated, err := replaceJSONField(content, "version", v)
+		return updated, v, err
+	case defaults.ApplyTypePyproject:
+		v, err := fileVersion(file, result, cfg, defaults.ModePep440)
+		if err != nil {
+			return "", "", err
+		}
+		updated, err := replaceTOMLVersion(content, []string{"project", "tool.poetry"}, v)
+		return updated, v, err
+	case defaults.ApplyTypeCargo:
+		v, err := fileVersion(file, result, cfg, defaults.ModeSemver)
+		if err != nil {
+			return "", "", err
+		}
+		updated, err := replaceTOMLVersion(content, []string{"package", "workspace.package"}, v)
+		return updated, v, err
+	case defaults.ApplyTypeHelm:
+		versions, err := version.NewHelmVersions(result, cfg)
+		if err != nil {
+			return "", "", err
+		}
+		updated, err := version.UpdateChartVersions(content, versions)
+		return updated, versions.Version, err
+	case defaults.ApplyTypeCsproj:
+		versions, err := version.NewDotnetVersions(result, cfg)
+		if err != nil {
+			return "", "", err
+		}
+		updated, replaced, err := replaceXMLElements(content, map[string]string{
+			"Project/PropertyGroup/Version":              versions.NuGetVersion,
+			"Project/PropertyGroup/PackageVersion":       versions.NuGetVersion,
+			"Project/PropertyGroup/AssemblyVersion":      versions.AssemblyVersion,
+			"Project/PropertyGroup/FileVersion":          versions.FileVersion,
+			"Project/PropertyGroup/InformationalVersion": versions.InformationalVersion,
+		})
+		if err == nil && replaced == 0 {
+			err = fmt.Errorf("no <Version> property found")
+		}
+		return updated, versions.NuGetVersion, err
+	case defaults.ApplyTypePom:
+		v, err := fileVersion(file, result, cfg, defaults.ModeMaven)
+		if err != nil {
+			return "", "", err
+		}
+		updated, replaced, err := replaceXMLElements(content, map[string]string{"project/version": v})
+		if err == nil && replaced == 0 {
+			err = fmt.Errorf("no <version> element found in <project>, the version may be inherited from the parent")
+		}
+		return updated, v, err
+	case defaults.ApplyTypeText:
+		v, err := fileVersion(file, result, cfg, defaults.ModeSemver)
+		if err != nil {
+			return "", "", err
+		}
+		lineEnd := "\n"
+		if strings.HasSuffix(content, "\r\n") {
+			lineEnd = "\r\n"
+		}
+		return v + lineEnd, v, nil
+	case defaults.ApplyTypeRegex:
+		v, err := fileVersion(file, result, cfg, defaults.ModeSemver)
+		if err != nil {
+			return "", "", err
+		}
+		updated, err := replaceRegex(content, file.Pattern, v)
+		return updated, v, err
+	}
+	return "", "", fmt.Errorf("unsupported file type '%s'", fileType)
+}
+
+// fileVersion converts the result to the mode of a file, or to defaultMode if the file has none
+// An empty defaultMode means the npm version.
+func fileVersion(file config.ApplyFile, result *version.Result, cfg *config.Config, defaultMode string) (string, error) {
+	mode := file.Mode
+	if mode == "" {
+		mode = defaultMode
+	}
+	if mode == "" {
+		npmVersion, err := version.NewNpmVersion(result, cfg)
+		return npmVersion.Version, err
+	}
+	return version.ConvertResult(result, cfg, mode)
+}
+
+// replaceJSONField replaces the string value of a key of the top-level JSON object
+func replaceJSONField(content, key, value string) (string, error) {
+	depth := 0
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case '"':
+			end, err := jsonStringEnd(content, i)
+			if err != nil {
+				return "", err
+			}
+			if depth == 1 && content[i+1:end] == key {
+				// A key is followed by a colon, a value isn't
+				rest := strings.TrimLeft(content[end+1:], " \t\r\n")
+				if strings.HasPrefix(rest, ":") {
+					valueStart := len(content) - len(strings.TrimLeft(rest[1:], " \t\r\n"))
+					if valueStart >= len(content) || content[valueStart] != '"' {
+						return "", fmt.Errorf("\"%s\" is not a string", key)
+					}
+					valueEnd, err := jsonStringEnd(content, valueStart)
+					if err != nil {
+						return "", err
+					}
+					quoted := strconv.Quote(value)
+					return content[:valueStart] + quoted + content[valueEnd+1:], nil
+				}
+			}
+			i = end
+		}
+	}
+	return "", fmt.Errorf("no top-level \"%s\" field found", key)
+}
+
+// jsonStringEnd returns the index of the closing quote of the JSON string starting at start
+func jsonStringEnd(content string, start int) (int, error) {
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string in JSON")
+}
+
+// replaceTOMLVersion replaces the version string of the first of the given tables that has one
+func replaceTOMLVersion(content string, tables []string, value string) (string, error) {
+	lines := strings.Split(content, "\n")
+	for _, table := range tables {
+		current := ""
+		for i, line := range lines {
+			if match := tomlTableHeader.FindStringSubmatch(line); match != nil {
+				current = match[2]
+				if match[1] == "[[" {
+					current = "[[" + current
+				}
+				continue
+			}
+			if current != table {
+				continue
+			}
+			if match := tomlVersionLine.FindStringSubmatch(line); match != nil {
+				quote := match[2][:1]
+				lines[i] = match[1] + quote + value + quote + match[3]
+				return strings.Join(lines, "\n"), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no version field found in [%s]", strings.Join(tables, "] or ["))
+}
+
+// replaceXMLElements replaces the text of the elements at the given paths (element names from the
+// root separated by "/"). Everything outside the replaced text is kept byte for byte. Returns the
+// number of elements replaced.
+func replaceXMLElements(content string, values map[string]string) (string, int, error) {
+	type replacement struct {
+		start, end int
+		value      string
+	}
+	var replacements []replacement
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	var stack []string
+	textStart := -1
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			// Only elements that contain text are replaced
+			textStart = -1
+			if _, ok := values[strings.Join(stack, "/")]; ok {
+				textStart = int(decoder.InputOffset())
+			}
+		case xml.EndElement:
+			path := strings.Join(stack, "/")
+			selfClosing := offset == textStart && strings.HasSuffix(content[:textStart], "/>")
+			if value, ok := values[path]; ok && textStart != -1 && !selfClosing {
+				current := strings.TrimSpace(content[textStart:offset])
+				if strings.Contains(current, "${") {
+					return "", 0, fmt.Errorf("<%s> is the property %s, use a regex file for the property instead", t.Name.Local, current)
+				}
+				var escaped bytes.Buffer
+				if err := xml.EscapeText(&escaped, []byte(value)); err != nil {
+					return "", 0, err
+				}
+				replacements = append(replacements, replacement{textStart, offset, escaped.String()})
+			}
+			textStart = -1
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	for i := len(replacements) - 1; i >= 0; i-- {
+		r := replacements[i]
+		content = content[:r.start] + r.value + content[r.end:]
+	}
+	return content, len(replacements), nil
+}
+
+// replaceRegex replaces the group named "version", or else the first capture group, of every match
+func replaceRegex(content, pattern, value string) (string, error) {
+	if pattern == "" {
+		return "", fmt.Errorf("type regex needs a pattern")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+	group := re.SubexpIndex("version")
+	if group == -1 {
+		group = 1
+	}
+	if re.NumSubexp() < group {
+		return "", fmt.Errorf("pattern '%s' has no capture group for the version", pattern)
+	}
+
+	matches := re.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("pattern '%s' doesn't match", pattern)
+	}
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[2*group], match[2*group+1]
+		if start == -1 {
+			continue
+		}
+		b.WriteString(content[last:start])
+		b.WriteString(value)
+		last = end
+	}
+	b.WriteString(content[last:])
+	return b.String(), nil
+}
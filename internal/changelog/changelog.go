@@ -0,0 +1,267 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/trondhindenes/autoversion/internal/git"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// Commit is a commit parsed as a Conventional Commit (https://www.conventionalcommits.org)
+type Commit struct {
+	SHA          string `json:"sha"`
+	Author       string `json:"author"`
+	Email        string `json:"email"`
+	Subject      string `json:"subject"`
+	Type         string `json:"type"` // lower case, empty when the subject isn't a Conventional Commit
+	Scope        string `json:"scope"`
+	Description  string `json:"description"` // the subject without type, scope and pull request number
+	Breaking     bool   `json:"breaking"`
+	BreakingNote string `json:"breakingNote"` // text of the BREAKING CHANGE footer, empty when there is none
+	PullRequest  string `json:"pullRequest"`  // number of a pull request referenced at the end of the subject, e.g. "(#123)"
+}
+
+// Options configures the links of the markdown changelog
+type Options struct {
+	CommitURL      string // Go template with SHA and ShortSHA, no links when empty
+	PullRequestURL string // Go template with Number, no links when empty
+}
+
+// section is a changelog section and the Conventional Commit types listed in it
+type section struct {
+	title string
+	types []string
+}
+
+// sections are the changelog sections in order
+// Commits of the types not listed (chore, ci, build, test, style) are left out, commits that
+// aren't Conventional Commits are listed under "Other Changes".
+var sections = []section{
+	{"Features", []string{"feat"}},
+	{"Bug Fixes", []string{"fix"}},
+	{"Performance Improvements", []string{"perf"}},
+	{"Reverts", []string{"revert"}},
+	{"Documentation", []string{"docs"}},
+	{"Code Refactoring", []string{"refactor"}},
+	{"Other Changes", []string{""}},
+}
+
+// conventionalSubject matches "type(scope)!: description"
+var conventionalSubject = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: *(.+)$`)
+
+// pullRequestRef matches a pull request number at the end of a subject, as added by squash merges
+var pullRequestRef = regexp.MustCompile(`\s*\(#(\d+)\)$`)
+
+// breakingFooter matches the BREAKING CHANGE footer of a commit message
+var breakingFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: *`)
+
+// Parse parses the message of a commit as a Conventional Commit
+func Parse(commit git.Commit) Commit {
+	parsed := Commit{
+		SHA:         commit.Hash.String(),
+		Author:      commit.Author,
+		Email:       commit.Email,
+		Subject:     commit.Subject(),
+		Description: commit.Subject(),
+	}
+	if match := pullRequestRef.FindStringSubmatch(parsed.Description); match != nil {
+		parsed.PullRequest = match[1]
+		parsed.Description = strings.TrimSuffix(parsed.Description, match[0])
+	}
+	if match := conventionalSubject.FindStringSubmatch(parsed.Description); match != nil {
+		parsed.Type = strings.ToLower(match[1])
+		parsed.Scope = match[2]
+		parsed.Breaking = match[3] == "!"
+		parsed.Description = match[4]
+	}
+
+	// The footer note runs to the end of its paragraph
+	if loc := breakingFooter.FindStringIndex(commit.Message); loc != nil {
+		note, _, _ := strings.Cut(commit.Message[loc[1]:], "\n\n")
+		parsed.Breaking = true
+		parsed.BreakingNote = strings.Join(strings.Fields(note), " ")
+	}
+	return parsed
+}
+
+// Markdown renders the changes of a release as a markdown changelog section
+func Markdown(changes *version.ChangeSet, opts Options) (string, error) {
+	commitURL, err := parseURLTemplate("changelogCommitUrl", opts.CommitURL)
+	if err != nil {
+		return "", err
+	}
+	pullRequestURL, err := parseURLTemplate("changelogPullRequestUrl", opts.PullRequestURL)
+	if err != nil {
+		return "", err
+	}
+
+	commits := parseCommits(changes.Commits)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (%s)\n", changes.Version, changes.Date.UTC().Format("2006-01-02"))
+
+	var breaking []Commit
+	for _, commit := range commits {
+		if commit.Breaking {
+			breaking = append(breaking, commit)
+		}
+	}
+	if len(breaking) > 0 {
+		b.WriteString("\n### BREAKING CHANGES\n\n")
+		for _, commit := range breaking {
+			if commit.BreakingNote != "" {
+				commit.Description = commit.BreakingNote
+			}
+			if err := writeEntry(&b, commit, commitURL, pullRequestURL); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	listed := false
+	for _, section := range sections {
+		var entries []Commit
+		for _, commit := range commits {
+			if isSectionType(section, commit.Type) {
+				entries = append(entries, commit)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section.title)
+		for _, commit := range entries {
+			if err := writeEntry(&b, commit, commitURL, pullRequestURL); err != nil {
+				return "", err
+			}
+		}
+		listed = true
+	}
+	if !listed && len(breaking) == 0 {
+		b.WriteString("\nNo notable changes.\n")
+	}
+	return b.String(), nil
+}
+
+// parseCommits parses commits as Conventional Commits
+func parseCommits(commits []git.Commit) []Commit {
+	parsed := make([]Commit, 0, len(commits))
+	for _, commit := range commits {
+		parsed = append(parsed, Parse(commit))
+	}
+	return parsed
+}
+
+// Prepend adds a changelog section to a changelog, above the sections of lower versions
+// A section for the same version is replaced, so prepending the same release again doesn't
+// change the changelog.
+func Prepend(changelog, section, releaseVersion string) string {
+	if strings.TrimSpace(changelog) == "" {
+		return "# Changelog\n\n" + section
+	}
+	section = strings.TrimSuffix(section, "\n") + "\n\n"
+
+	lines := strings.SplitAfter(changelog, "\n")
+	start, end := -1, len(lines)
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+		if start != -1 {
+			end = i
+			break
+		}
+		existing := headingVersion(line)
+		if existing == releaseVersion {
+			start = i
+		} else if c, err := version.CompareSemver(existing, releaseVersion); err == nil && c < 0 {
+			// Insert the section above the first lower version
+			start, end = i, i
+			break
+		}
+	}
+
+	updated := strings.TrimRight(changelog, "\n") + "\n\n" + section
+	if start != -1 {
+		updated = strings.Join(lines[:start], "") + section + strings.Join(lines[end:], "")
+	}
+	return strings.TrimRight(updated, "\n") + "\n"
+}
+
+// headingVersion returns the version of a "## 1.2.3 (date)" or "## [1.2.3](url) - date" heading
+func headingVersion(heading string) string {
+	fields := strings.Fields(strings.TrimPrefix(heading, "## "))
+	if len(fields) == 0 {
+		return ""
+	}
+	word := strings.TrimPrefix(fields[0], "[")
+	if end := strings.Index(word, "]"); end != -1 {
+		word = word[:end]
+	}
+	return strings.TrimPrefix(word, "v")
+}
+
+// isSectionType reports whether commits of a type are listed in a section
+func isSectionType(s section, commitType string) bool {
+	if commitType == "" {
+		return s.types[0] == ""
+	}
+	for _, t := range s.types {
+		if t == commitType {
+			return true
+		}
+	}
+	return false
+}
+
+// writeEntry writes the list entry of a commit
+func writeEntry(b *strings.Builder, commit Commit, commitURL, pullRequestURL *template.Template) error {
+	b.WriteString("* ")
+	if commit.Scope != "" {
+		fmt.Fprintf(b, "**%s:** ", commit.Scope)
+	}
+	b.WriteString(commit.Description)
+
+	if commit.PullRequest != "" {
+		link, err := renderLink("#"+commit.PullRequest, pullRequestURL, map[string]string{"Number": commit.PullRequest})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, " (%s)", link)
+	}
+	shortSHA := commit.SHA[:min(7, len(commit.SHA))]
+	link, err := renderLink(shortSHA, commitURL, map[string]string{"SHA": commit.SHA, "ShortSHA": shortSHA})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(b, " (%s)\n", link)
+	return nil
+}
+
+// renderLink returns a markdown link to the rendered URL template, or the text when there is no
+// template
+func renderLink(text string, urlTemplate *template.Template, data map[string]string) (string, error) {
+	if urlTemplate == nil {
+		return text, nil
+	}
+	var url strings.Builder
+	if err := urlTemplate.Execute(&url, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", urlTemplate.Name(), err)
+	}
+	return fmt.Sprintf("[%s](%s)", text, url.String()), nil
+}
+
+// parseURLTemplate parses a link URL template, nil when it is empty
+func parseURLTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,166 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/trondhindenes/autoversion/internal/git"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+func testCommit(sha, message string) git.Commit {
+	return git.Commit{Hash: plumbing.NewHash(sha), Author: "Test User", Email: "test@example.com", Message: message}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected Commit
+	}{
+		{"feat: add bump command", Commit{Type: "feat", Description: "add bump command"}},
+		{"Fix(cli): handle empty tags (#12)\n\nDetails", Commit{Type: "fix", Scope: "cli", Description: "handle empty tags", PullRequest: "12"}},
+		{"refactor(api)!: rename flags", Commit{Type: "refactor", Scope: "api", Description: "rename flags", Breaking: true}},
+		{"feat: drop v1\n\nBREAKING CHANGE: the v1 API\nis gone\n\nRefs: #3", Commit{Type: "feat", Description: "drop v1", Breaking: true, BreakingNote: "the v1 API is gone"}},
+		{"Update the readme (#7)", Commit{Description: "Update the readme", PullRequest: "7"}},
+		{"Merge branch 'main': fixes", Commit{Description: "Merge branch 'main': fixes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			parsed := Parse(testCommit("0123456789abcdef0123456789abcdef01234567", tt.message))
+			tt.expected.SHA = "0123456789abcdef0123456789abcdef01234567"
+			tt.expected.Author = "Test User"
+			tt.expected.Email = "test@example.com"
+			tt.expected.Subject, _, _ = strings.Cut(tt.message, "\n")
+			if parsed != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, parsed)
+			}
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	changes := &version.ChangeSet{
+		Version: "1.3.0",
+		Date:    time.Date(2026, 1, 18, 23, 30, 0, 0, time.FixedZone("PST", -8*3600)),
+		Commits: []git.Commit{
+			testCommit("1111111111111111111111111111111111111111", "Update the readme"),
+			testCommit("2222222222222222222222222222222222222222", "chore: update dependencies"),
+			testCommit("3333333333333333333333333333333333333333", "fix(cli): handle empty tags (#12)"),
+			testCommit("4444444444444444444444444444444444444444", "feat!: drop old flags"),
+			testCommit("5555555555555555555555555555555555555555", "feat(bump): add prerelease labels"),
+		},
+	}
+
+	markdown, err := Markdown(changes, Options{})
+	if err != nil {
+		t.Fatalf("Markdown failed: %v", err)
+	}
+	expected := `## 1.3.0 (2026-01-19)
+
+### BREAKING CHANGES
+
+* drop old flags (4444444)
+
+### Features
+
+* drop old flags (4444444)
+* **bump:** add prerelease labels (5555555)
+
+### Bug Fixes
+
+* **cli:** handle empty tags (#12) (3333333)
+
+### Other Changes
+
+* Update the readme (1111111)
+`
+	if markdown != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, markdown)
+	}
+
+	markdown, err = Markdown(&version.ChangeSet{Version: "1.3.0", Commits: changes.Commits[2:3]}, Options{
+		CommitURL:      "https://github.com/org/app/commit/{{.SHA}}",
+		PullRequestURL: "https://github.com/org/app/pull/{{.Number}}",
+	})
+	if err != nil {
+		t.Fatalf("Markdown failed: %v", err)
+	}
+	expected = `## 1.3.0 (0001-01-01)
+
+### Bug Fixes
+
+* **cli:** handle empty tags ([#12](https://github.com/org/app/pull/12)) ([3333333](https://github.com/org/app/commit/3333333333333333333333333333333333333333))
+`
+	if markdown != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, markdown)
+	}
+
+	markdown, err = Markdown(&version.ChangeSet{Version: "1.3.1", Commits: changes.Commits[1:2]}, Options{})
+	if err != nil {
+		t.Fatalf("Markdown failed: %v", err)
+	}
+	if markdown != "## 1.3.1 (0001-01-01)\n\nNo notable changes.\n" {
+		t.Errorf("Expected no notable changes, got:\n%s", markdown)
+	}
+
+	if _, err := Markdown(changes, Options{CommitURL: "{{.Sha}}"}); err == nil {
+		t.Errorf("Expected an error for an unknown template field")
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	section := "## 1.2.0 (2026-01-18)\n\n### Features\n\n* new (1111111)\n"
+
+	tests := []struct {
+		name     string
+		existing string
+		expected string
+	}{
+		{
+			name:     "new file",
+			existing: "",
+			expected: "# Changelog\n\n" + section,
+		},
+		{
+			name:     "above older releases",
+			existing: "# Changelog\n\nAll notable changes.\n\n## 1.1.0 (2026-01-01)\n\n* old\n",
+			expected: "# Changelog\n\nAll notable changes.\n\n" + section + "\n## 1.1.0 (2026-01-01)\n\n* old\n",
+		},
+		{
+			name:     "below newer releases",
+			existing: "# Changelog\n\n## [1.3.0](https://example.com) - 2026-02-01\n\n* newer\n\n## v1.1.0\n\n* old\n",
+			expected: "# Changelog\n\n## [1.3.0](https://example.com) - 2026-02-01\n\n* newer\n\n" + section + "\n## v1.1.0\n\n* old\n",
+		},
+		{
+			name:     "replaces the same release",
+			existing: "# Changelog\n\n## 1.2.0 (2026-01-17)\n\n* outdated\n\n## 1.1.0\n\n* old\n",
+			expected: "# Changelog\n\n" + section + "\n## 1.1.0\n\n* old\n",
+		},
+		{
+			name:     "replaces the last release",
+			existing: "# Changelog\n\n## 1.2.0 (2026-01-17)\n\n* outdated\n",
+			expected: "# Changelog\n\n" + section,
+		},
+		{
+			name:     "without releases",
+			existing: "# Changelog\n",
+			expected: "# Changelog\n\n" + section,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := Prepend(tt.existing, section, "1.2.0")
+			if updated != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, updated)
+			}
+			// Prepending again doesn't change anything
+			if again := Prepend(updated, section, "1.2.0"); again != updated {
+				t.Errorf("Expected prepending again to keep:\n%s\ngot:\n%s", updated, again)
+			}
+		})
+	}
+}
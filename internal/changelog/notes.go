@@ -0,0 +1,65 @@
+package changelog
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// ReleaseNotes is the structured data of a release, for release tooling
+type ReleaseNotes struct {
+	Version         string        `json:"version"`
+	PreviousVersion string        `json:"previousVersion"`
+	Tag             string        `json:"tag"`         // tag of the release, empty when it isn't tagged yet
+	PreviousTag     string        `json:"previousTag"` // tag or ref the commits are listed since
+	SHA             string        `json:"sha"`
+	Date            time.Time     `json:"date"`
+	Commits         []Commit      `json:"commits"`
+	Contributors    []Contributor `json:"contributors"`
+}
+
+// Contributor is an author of commits in a release
+type Contributor struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Commits int    `json:"commits"`
+}
+
+// NewReleaseNotes returns the release notes of the changes of a release
+// Contributors are identified by email address and sorted by their number of commits.
+func NewReleaseNotes(changes *version.ChangeSet) ReleaseNotes {
+	notes := ReleaseNotes{
+		Version:         changes.Version,
+		PreviousVersion: changes.PreviousVersion,
+		Tag:             changes.Tag,
+		PreviousTag:     changes.PreviousTag,
+		SHA:             changes.SHA,
+		Date:            changes.Date,
+		Commits:         parseCommits(changes.Commits),
+		Contributors:    []Contributor{},
+	}
+
+	index := make(map[string]int)
+	for _, commit := range notes.Commits {
+		key := strings.ToLower(commit.Email)
+		if key == "" {
+			key = commit.Author
+		}
+		if i, ok := index[key]; ok {
+			notes.Contributors[i].Commits++
+			continue
+		}
+		index[key] = len(notes.Contributors)
+		notes.Contributors = append(notes.Contributors, Contributor{Name: commit.Author, Email: commit.Email, Commits: 1})
+	}
+	sort.SliceStable(notes.Contributors, func(i, j int) bool {
+		a, b := notes.Contributors[i], notes.Contributors[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	return notes
+}
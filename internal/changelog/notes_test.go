@@ -0,0 +1,60 @@
+package changelog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/trondhindenes/autoversion/internal/git"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+func TestNewReleaseNotes(t *testing.T) {
+	changes := &version.ChangeSet{
+		Version:         "1.3.0",
+		PreviousVersion: "1.2.0",
+		PreviousTag:     "v1.2.0",
+		SHA:             "3333333333333333333333333333333333333333",
+		Commits: []git.Commit{
+			{Hash: plumbing.NewHash("3333333333333333333333333333333333333333"), Author: "Bea", Email: "bea@example.com", Message: "fix(cli): handle empty tags (#12)"},
+			{Hash: plumbing.NewHash("2222222222222222222222222222222222222222"), Author: "Al", Email: "al@example.com", Message: "docs: usage"},
+			{Hash: plumbing.NewHash("1111111111111111111111111111111111111111"), Author: "Bea B.", Email: "Bea@example.com", Message: "feat!: drop old flags"},
+		},
+	}
+
+	notes := NewReleaseNotes(changes)
+	if notes.Version != "1.3.0" || notes.PreviousVersion != "1.2.0" || notes.Tag != "" {
+		t.Errorf("Unexpected versions: %+v", notes)
+	}
+	if len(notes.Commits) != 3 {
+		t.Fatalf("Expected 3 commits, got %d", len(notes.Commits))
+	}
+	first := notes.Commits[0]
+	if first.Type != "fix" || first.Scope != "cli" || first.Subject != "fix(cli): handle empty tags (#12)" || first.PullRequest != "12" || first.Breaking {
+		t.Errorf("Unexpected commit: %+v", first)
+	}
+	if !notes.Commits[2].Breaking {
+		t.Errorf("Expected a breaking commit: %+v", notes.Commits[2])
+	}
+
+	// Contributors are identified by email address, the most active first
+	expected := []Contributor{{Name: "Bea", Email: "bea@example.com", Commits: 2}, {Name: "Al", Email: "al@example.com", Commits: 1}}
+	if len(notes.Contributors) != len(expected) {
+		t.Fatalf("Expected contributors %+v, got %+v", expected, notes.Contributors)
+	}
+	for i := range expected {
+		if notes.Contributors[i] != expected[i] {
+			t.Errorf("Expected contributors %+v, got %+v", expected, notes.Contributors)
+		}
+	}
+
+	// A release without commits has empty lists, not null
+	output, err := json.Marshal(NewReleaseNotes(&version.ChangeSet{Version: "1.3.0"}))
+	if err != nil {
+		t.Fatalf("Failed to encode JSON: %v", err)
+	}
+	if !strings.Contains(string(output), `"commits":[]`) || !strings.Contains(string(output), `"contributors":[]`) {
+		t.Errorf("Expected empty lists, got %s", output)
+	}
+}
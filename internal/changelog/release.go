@@ -0,0 +1,208 @@
+package changelog
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// ReleaseRules decide which commits are releasable
+type ReleaseRules struct {
+	Types        []string         // Conventional Commit types, "other" for commits that aren't Conventional Commits
+	Paths        []string         // glob patterns of releasable paths, "!" excludes; empty means all paths
+	SkipPatterns []*regexp.Regexp // commit messages that are never releasable
+}
+
+// ReleaseDecision is whether the changes since the last tag should be released, and why
+type ReleaseDecision struct {
+	Release bool
+	Reason  string
+	Commit  *Commit // the first releasable commit, nil when there is none
+}
+
+// NewReleaseRules returns the configured release rules, with the defaults for the unset ones
+func NewReleaseRules(cfg *config.Config) (ReleaseRules, error) {
+	rules := ReleaseRules{Types: defaults.DefaultReleaseTypes, Paths: cfg.ReleasePaths}
+	if len(cfg.ReleaseTypes) > 0 {
+		rules.Types = cfg.ReleaseTypes
+	}
+	skipPatterns := defaults.DefaultReleaseSkipPatterns
+	if cfg.ReleaseSkipPatterns != nil {
+		skipPatterns = cfg.ReleaseSkipPatterns
+	}
+	for _, pattern := range skipPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return ReleaseRules{}, fmt.Errorf("invalid releaseSkipPatterns pattern '%s': %w", pattern, err)
+		}
+		rules.SkipPatterns = append(rules.SkipPatterns, re)
+	}
+	return rules, nil
+}
+
+// ShouldRelease decides whether the changes since the last tag contain a releasable commit
+// A commit is releasable when its message doesn't match a skip pattern, it is a breaking change
+// or of a release type, and it changes a release path. files returns the paths a commit changes,
+// it is only called when there are path rules.
+func ShouldRelease(changes *version.ChangeSet, rules ReleaseRules, files func(sha string) ([]string, error)) (ReleaseDecision, error) {
+	since := "since " + changes.PreviousTag
+	if changes.PreviousTag == "" {
+		since = "in the history"
+	}
+	if changes.Tag != "" {
+		return ReleaseDecision{Reason: fmt.Sprintf("HEAD is already tagged %s", changes.Tag)}, nil
+	}
+	if len(changes.Commits) == 0 {
+		return ReleaseDecision{Reason: fmt.Sprintf("no commits %s", since)}, nil
+	}
+
+	skipped, otherTypes, otherPaths := 0, map[string]int{}, 0
+	for _, gitCommit := range changes.Commits {
+		commit := Parse(gitCommit)
+		if matchesAny(rules.SkipPatterns, gitCommit.Message) {
+			skipped++
+			continue
+		}
+		commitType := commit.Type
+		if commitType == "" {
+			commitType = defaults.ReleaseTypeOther
+		}
+		if !commit.Breaking && !contains(rules.Types, commitType) {
+			otherTypes[commitType]++
+			continue
+		}
+		if len(rules.Paths) > 0 {
+			changed, err := files(commit.SHA)
+			if err != nil {
+				return ReleaseDecision{}, err
+			}
+			if !anyReleasePath(rules.Paths, changed) {
+				otherPaths++
+				continue
+			}
+		}
+
+		kind := "a " + commitType + " commit"
+		switch {
+		case commit.Breaking:
+			kind = "a breaking change"
+		case commit.Type == "":
+			kind = "a commit that isn't a Conventional Commit"
+		}
+		return ReleaseDecision{
+			Release: true,
+			Reason:  fmt.Sprintf("%q (%s) is %s %s", commit.Subject, commit.SHA[:7], kind, since),
+			Commit:  &commit,
+		}, nil
+	}
+
+	var reasons []string
+	for _, commitType := range sortedKeys(otherTypes) {
+		reasons = append(reasons, fmt.Sprintf("%d %s", otherTypes[commitType], commitType))
+	}
+	if otherPaths > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d only changing paths outside releasePaths", otherPaths))
+	}
+	if skipped > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d skipped by message", skipped))
+	}
+	return ReleaseDecision{
+		Reason: fmt.Sprintf("none of the %d commits %s is releasable (%s)", len(changes.Commits), since, strings.Join(reasons, ", ")),
+	}, nil
+}
+
+// anyReleasePath reports whether any of the paths is included by the path rules
+// A path is included when it matches a pattern (or there are only exclusions) and doesn't match
+// an exclusion.
+func anyReleasePath(patterns, paths []string) bool {
+	for _, p := range paths {
+		included, hasIncludes := false, false
+		excluded := false
+		for _, pattern := range patterns {
+			if exclusion, ok := strings.CutPrefix(pattern, "!"); ok {
+				excluded = excluded || matchPath(exclusion, p)
+				continue
+			}
+			hasIncludes = true
+			included = included || matchPath(pattern, p)
+		}
+		if (included || !hasIncludes) && !excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches a slash-separated path against a glob pattern, where "**" matches any number
+// of directories, a pattern ending in "/" matches everything below the directory and a pattern
+// without "/" matches the file name in any directory
+func matchPath(pattern, p string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		return globRegexp(pattern).MatchString(path.Base(p))
+	}
+	return globRegexp(pattern).MatchString(p)
+}
+
+// globRegexp converts a glob pattern with "**" to a regular expression
+func globRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// matchesAny reports whether the text matches any of the regular expressions
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	for _, re := range patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether the list contains the value
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
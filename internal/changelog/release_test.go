@@ -0,0 +1,103 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/git"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+func TestShouldRelease(t *testing.T) {
+	// The files changed by each commit, by subject
+	changedFiles := map[string][]string{
+		"docs: usage":             {"docs/usage.md"},
+		"fix: typo in readme":     {"README.md"},
+		"fix: handle empty tags":  {"src/tags.go", "src/tags_test.go"},
+		"Update the guide":        {"docs/guide.md"},
+		"chore!: require go 1.25": {"go.mod"},
+	}
+
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		subjects []string
+		release  bool
+		reason   string
+	}{
+		{"fix", config.Config{}, []string{"docs: usage", "fix: handle empty tags"}, true, `"fix: handle empty tags" (`},
+		{"only docs and chores", config.Config{}, []string{"docs: usage", "chore: update dependencies", "docs: more usage"}, false, "(1 chore, 2 docs)"},
+		{"skip pattern", config.Config{}, []string{"fix: handle empty tags\n\n[skip release]"}, false, "1 skipped by message"},
+		{"custom skip pattern", config.Config{ReleaseSkipPatterns: []string{`(?i)\[no ?release\]`}}, []string{"fix: handle empty tags [No Release]"}, false, "1 skipped by message"},
+		{"breaking change of any type", config.Config{}, []string{"chore!: require go 1.25"}, true, "is a breaking change"},
+		{"not a Conventional Commit", config.Config{}, []string{"Update the guide"}, true, "isn't a Conventional Commit"},
+		{"without other", config.Config{ReleaseTypes: []string{"feat", "fix"}}, []string{"Update the guide"}, false, "(1 other)"},
+		{"excluded paths", config.Config{ReleasePaths: []string{"!docs/", "!*.md"}}, []string{"Update the guide", "fix: typo in readme"}, false, "2 only changing paths outside releasePaths"},
+		{"included paths", config.Config{ReleasePaths: []string{"src/**", "!**/*_test.go"}}, []string{"fix: typo in readme", "fix: handle empty tags"}, true, `"fix: handle empty tags"`},
+		{"no commits", config.Config{}, nil, false, "no commits since v1.2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := &version.ChangeSet{Version: "1.2.1", PreviousTag: "v1.2.0"}
+			shas := make(map[string]string)
+			for i, message := range tt.subjects {
+				sha := fmt.Sprintf("%040d", i+1)
+				changes.Commits = append(changes.Commits, git.Commit{Hash: plumbing.NewHash(sha), Message: message})
+				shas[sha], _, _ = strings.Cut(message, "\n")
+			}
+			files := func(sha string) ([]string, error) {
+				return changedFiles[shas[sha]], nil
+			}
+
+			rules, err := NewReleaseRules(&tt.cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			decision, err := ShouldRelease(changes, rules, files)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if decision.Release != tt.release || !strings.Contains(decision.Reason, tt.reason) {
+				t.Errorf("Expected release=%v with reason containing %q, got release=%v: %s", tt.release, tt.reason, decision.Release, decision.Reason)
+			}
+		})
+	}
+
+	decision, err := ShouldRelease(&version.ChangeSet{Tag: "v1.2.0"}, ReleaseRules{}, nil)
+	if err != nil || decision.Release || decision.Reason != "HEAD is already tagged v1.2.0" {
+		t.Errorf("Expected no release of a tagged commit, got %+v (%v)", decision, err)
+	}
+
+	if _, err := NewReleaseRules(&config.Config{ReleaseSkipPatterns: []string{"[skip"}}); err == nil {
+		t.Errorf("Expected an error for an invalid skip pattern")
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"*.md", "README.md", true},
+		{"*.md", "docs/guide/intro.md", true},
+		{"docs/", "docs/guide/intro.md", true},
+		{"docs/", "src/docs.go", false},
+		{"src/*.go", "src/main.go", true},
+		{"src/*.go", "src/cmd/main.go", false},
+		{"src/**", "src/cmd/main.go", true},
+		{"**/*_test.go", "main_test.go", true},
+		{"**/*_test.go", "src/cmd/main_test.go", true},
+		{"/go.mod", "go.mod", true},
+		{"charts/*/Chart.yaml", "charts/app/Chart.yaml", true},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.path); got != tt.matches {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.matches)
+		}
+	}
+}
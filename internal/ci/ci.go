@@ -3,6 +3,7 @@ package ci
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/trondhindenes/autoversion/internal/config"
@@ -66,3 +67,22 @@ func DetectBranch(cfg *config.Config) (string, bool) {
 
 	return "", false
 }
+
+// DetectProvider returns the name of the CI provider autoversion is running in (a key of
+// defaults.WellKnownCIProviders), based on the environment variable each provider always sets
+// Unlike DetectBranch, this doesn't depend on UseCIBranch.
+func DetectProvider() (string, bool) {
+	names := make([]string, 0, len(defaults.WellKnownCIProviders))
+	for name := range defaults.WellKnownCIProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		envVar := defaults.WellKnownCIProviders[name].DetectEnvVar
+		if envVar != "" && os.Getenv(envVar) != "" {
+			return name, true
+		}
+	}
+	return "", false
+}
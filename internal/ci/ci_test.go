@@ -149,6 +149,36 @@ func TestWellKnownProviders(t *testing.T) {
 	}
 }
 
+func TestDetectProvider(t *testing.T) {
+	detectEnvVars := []string{"GITHUB_ACTIONS", "GITLAB_CI", "CIRCLECI", "TRAVIS", "JENKINS_URL", "TF_BUILD", "TEAMCITY_VERSION"}
+	clear := func() {
+		for _, envVar := range detectEnvVars {
+			t.Setenv(envVar, "")
+		}
+	}
+
+	clear()
+	if provider, found := DetectProvider(); found {
+		t.Errorf("Expected no provider, got %s", provider)
+	}
+
+	tests := map[string]string{
+		"GITHUB_ACTIONS":   "github-actions",
+		"GITLAB_CI":        "gitlab-ci",
+		"TF_BUILD":         "azure-pipelines",
+		"TEAMCITY_VERSION": "teamcity",
+		"JENKINS_URL":      "jenkins",
+	}
+	for envVar, expected := range tests {
+		clear()
+		t.Setenv(envVar, "true")
+		provider, found := DetectProvider()
+		if !found || provider != expected {
+			t.Errorf("With %s set, expected %s, got %s (found: %v)", envVar, expected, provider, found)
+		}
+	}
+}
+
 // Helper function to create a bool pointer
 func boolPtr(b bool) *bool {
 	return &b
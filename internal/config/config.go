@@ -3,22 +3,76 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/invopop/jsonschema"
 )
 
 // Config represents the application configuration
 type Config struct {
-	MainBranch            string   `json:"mainBranch,omitempty" yaml:"mainBranch,omitempty" jsonschema:"title=Main Branch (deprecated),description=Deprecated: Use mainBranches instead. The name of the main branch"`
-	MainBranches          []string `json:"mainBranches,omitempty" yaml:"mainBranches,omitempty" jsonschema:"title=Main Branches,description=List of branch names to treat as main branches (default: ['main' 'master']). The first matching branch found is used"`
-	MainBranchBehavior    *string  `json:"mainBranchBehavior,omitempty" yaml:"mainBranchBehavior,omitempty" jsonschema:"title=Main Branch Behavior,description=Behavior for non-tagged commits on main branch: 'release' (default) creates release versions '1.0.0' or 'pre' creates prerelease versions '1.0.0-pre.0',enum=release,enum=pre"`
-	Mode                  *string  `json:"mode,omitempty" yaml:"mode,omitempty" jsonschema:"title=Version Mode,description=Version format mode: 'json' (default) outputs JSON with semver and pep440 formats or 'semver' outputs standard semantic versioning or 'pep440' outputs Python PEP 440 compatible versions,enum=json,enum=semver,enum=pep440"`
-	TagPrefix             *string  `json:"tagPrefix,omitempty" yaml:"tagPrefix,omitempty" jsonschema:"title=Tag Prefix,description=Prefix to strip from git tags (e.g. 'PRODUCT/' to convert 'PRODUCT/2.0.0' to '2.0.0'). Default is empty string"`
-	VersionPrefix         *string  `json:"versionPrefix,omitempty" yaml:"versionPrefix,omitempty" jsonschema:"title=Version Prefix,description=Prefix to add to the generated version output (e.g. 'v' to output 'v1.0.0' instead of '1.0.0'). Default is empty string"`
-	InitialVersion        *string  `json:"initialVersion,omitempty" yaml:"initialVersion,omitempty" jsonschema:"title=Initial Version,description=The initial version to use when no tags exist in the repository (e.g. '0.0.1' or '1.0.0'). Default is '1.0.0'. Must be valid semver"`
-	UseCIBranch           *bool    `json:"useCIBranch,omitempty" yaml:"useCIBranch,omitempty" jsonschema:"title=Use CI Branch,description=Whether to detect and use the actual branch name from CI environment variables. Useful for PR builds where CI checks out a temporary branch. Default is false"`
-	FailOnOutdatedBase    *bool    `json:"failOnOutdatedBase,omitempty" yaml:"failOnOutdatedBase,omitempty" jsonschema:"title=Fail On Outdated Base,description=When running on a feature branch if true and the main branch has been tagged after this branch diverged autoversion will exit with an error instead of just warning. Default is false"`
-	OutdatedBaseCheckMode *string  `json:"outdatedBaseCheckMode,omitempty" yaml:"outdatedBaseCheckMode,omitempty" jsonschema:"title=Outdated Base Check Mode,description=Controls what triggers the outdated base warning/error on feature branches: 'tagged' (default) only warns when main has new tags or 'all' warns when main has any new commits since branching,enum=tagged,enum=all"`
+	MainBranch                 string            `json:"mainBranch,omitempty" yaml:"mainBranch,omitempty" jsonschema:"title=Main Branch (deprecated),description=Deprecated: Use mainBranches instead. The name of the main branch"`
+	MainBranches               []string          `json:"mainBranches,omitempty" yaml:"mainBranches,omitempty" jsonschema:"title=Main Branches,description=List of branch names to treat as main branches (default: ['main' 'master']). The first matching branch found is used"`
+	MainBranchBehavior         *string           `json:"mainBranchBehavior,omitempty" yaml:"mainBranchBehavior,omitempty" jsonschema:"title=Main Branch Behavior,description=Behavior for non-tagged commits on main branch: 'release' (default) creates release versions '1.0.0' or 'pre' creates prerelease versions '1.0.0-pre.0',enum=release,enum=pre"`
+	Mode                       *string           `json:"mode,omitempty" yaml:"mode,omitempty" jsonschema:"title=Version Mode,description=Version format mode: 'json' (default) outputs JSON with semver and pep440 formats or 'semver' outputs standard semantic versioning or 'pep440' outputs Python PEP 440 compatible versions or 'maven' outputs Maven versions with SNAPSHOT for prereleases or 'dotnet' outputs JSON with the .NET assembly and file and informational and NuGet versions or 'deb' and 'rpm' output package versions with '~' for prereleases or 'npm' outputs JSON with the npm version and dist-tag or 'helm' outputs JSON with the chart version and appVersion,enum=json,enum=semver,enum=pep440,enum=maven,enum=dotnet,enum=deb,enum=rpm,enum=npm,enum=helm"`
+	TagPrefix                  *string           `json:"tagPrefix,omitempty" yaml:"tagPrefix,omitempty" jsonschema:"title=Tag Prefix,description=Prefix to strip from git tags (e.g. 'PRODUCT/' to convert 'PRODUCT/2.0.0' to '2.0.0'). Default is empty string"`
+	VersionPrefix              *string           `json:"versionPrefix,omitempty" yaml:"versionPrefix,omitempty" jsonschema:"title=Version Prefix,description=Prefix to add to the generated version output (e.g. 'v' to output 'v1.0.0' instead of '1.0.0'). Default is empty string"`
+	InitialVersion             *string           `json:"initialVersion,omitempty" yaml:"initialVersion,omitempty" jsonschema:"title=Initial Version,description=The initial version to use when no tags exist in the repository (e.g. '0.0.1' or '1.0.0'). Default is '1.0.0'. Must be valid semver"`
+	UseCIBranch                *bool             `json:"useCIBranch,omitempty" yaml:"useCIBranch,omitempty" jsonschema:"title=Use CI Branch,description=Whether to detect and use the actual branch name from CI environment variables. Useful for PR builds where CI checks out a temporary branch. Default is false"`
+	FailOnOutdatedBase         *bool             `json:"failOnOutdatedBase,omitempty" yaml:"failOnOutdatedBase,omitempty" jsonschema:"title=Fail On Outdated Base,description=When running on a feature branch if true and the main branch has been tagged after this branch diverged autoversion will exit with an error instead of just warning. Default is false"`
+	OutdatedBaseCheckMode      *string           `json:"outdatedBaseCheckMode,omitempty" yaml:"outdatedBaseCheckMode,omitempty" jsonschema:"title=Outdated Base Check Mode,description=Controls what triggers the outdated base warning/error on feature branches: 'tagged' (default) only warns when main has new tags or 'all' warns when main has any new commits since branching,enum=tagged,enum=all"`
+	Remote                     string            `json:"remote,omitempty" yaml:"remote,omitempty" jsonschema:"title=Remote,description=Name of the git remote used to look up remote-tracking branches (default: 'origin'). Use remotes to configure several"`
+	Remotes                    []string          `json:"remotes,omitempty" yaml:"remotes,omitempty" jsonschema:"title=Remotes,description=Ordered list of git remotes used to look up remote-tracking branches (e.g. ['upstream' 'origin']). The first remote that has a branch is used. Takes precedence over remote"`
+	MainBranchSource           *string           `json:"mainBranchSource,omitempty" yaml:"mainBranchSource,omitempty" jsonschema:"title=Main Branch Source,description=Which reference of the main branch to use: 'local' (default) uses the local branch and falls back to the remote or 'remote' uses the remote-tracking branch and falls back to the local branch. Use 'remote' to avoid versioning against a stale local main branch. Also decides which of them is authoritative when they have diverged,enum=local,enum=remote"`
+	FailOnMainBranchDivergence *bool             `json:"failOnMainBranchDivergence,omitempty" yaml:"failOnMainBranchDivergence,omitempty" jsonschema:"title=Fail On Main Branch Divergence,description=If true autoversion exits with an error instead of just warning when the local main branch and its remote-tracking branch point to different commits. Default is false"`
+	OutputTemplate             *string           `json:"outputTemplate,omitempty" yaml:"outputTemplate,omitempty" jsonschema:"title=Output Template,description=Go text/template used to format the output instead of mode (e.g. '{{.Major}}.{{.Minor}}-{{.ShortSHA}}'). Helper functions: sanitize and truncate and pep440 and lower and upper and replace"`
+	GitHubActionsOutput        *bool             `json:"githubActionsOutput,omitempty" yaml:"githubActionsOutput,omitempty" jsonschema:"title=GitHub Actions Output,description=When running in GitHub Actions write the version and every JSON output field as step outputs to $GITHUB_OUTPUT and export selected fields as AUTOVERSION_* variables to $GITHUB_ENV and add a summary to $GITHUB_STEP_SUMMARY. Default is true"`
+	VariablePrefix             *string           `json:"variablePrefix,omitempty" yaml:"variablePrefix,omitempty" jsonschema:"title=Variable Prefix,description=Prefix of the variable names used by --output env/dotenv/make/powershell and the CI outputs (e.g. 'AUTOVERSION_' gives AUTOVERSION_SEMVER). Default is 'AUTOVERSION_'. May be empty"`
+	Pep440BranchSegment        *string           `json:"pep440BranchSegment,omitempty" yaml:"pep440BranchSegment,omitempty" jsonschema:"title=PEP 440 Branch Segment,description=PEP 440 segment for branch prereleases: 'a' (default) gives '1.2.0a3' or 'dev' gives '1.2.0.dev3' (also 'b' or 'rc'),enum=a,enum=b,enum=rc,enum=dev"`
+	Pep440Labels               map[string]string `json:"pep440Labels,omitempty" yaml:"pep440Labels,omitempty" jsonschema:"title=PEP 440 Labels,description=PEP 440 segment (a or b or rc or dev) for prerelease labels on top of the defaults (pre/alpha -> a and beta -> b and rc -> rc and dev -> dev). Labels not listed are branch names and use pep440BranchSegment"`
+	Pep440LocalLabel           *bool             `json:"pep440LocalLabel,omitempty" yaml:"pep440LocalLabel,omitempty" jsonschema:"title=PEP 440 Local Label,description=Add the branch name of branch prereleases as a PEP 440 local version label (e.g. '1.2.0.dev3+feat.x') so builds of different branches don't collide. PyPI doesn't accept local labels. Default is false"`
+	MavenSnapshotStyle         *string           `json:"mavenSnapshotStyle,omitempty" yaml:"mavenSnapshotStyle,omitempty" jsonschema:"title=Maven Snapshot Style,description=How mode 'maven' writes prereleases: 'snapshot' (default) gives '1.2.4-SNAPSHOT' or 'timestamp' gives unique snapshot versions like '1.2.4-20260118.153012-3' from the commit time and build number,enum=snapshot,enum=timestamp"`
+	DotnetAssemblyVersion      *string           `json:"dotnetAssemblyVersion,omitempty" yaml:"dotnetAssemblyVersion,omitempty" jsonschema:"title=.NET Assembly Version,description=AssemblyVersion of mode 'dotnet': 'major' (default) gives 'Major.0.0.0' or 'minor' gives 'Major.Minor.0.0',enum=major,enum=minor"`
+	DotnetPropsFile            *string           `json:"dotnetPropsFile,omitempty" yaml:"dotnetPropsFile,omitempty" jsonschema:"title=.NET Props File,description=Path of a Directory.Build.props file to write the .NET versions to (e.g. 'Directory.Build.props'). An existing file is only overwritten if autoversion generated it. Default is empty (no file)"`
+	PackageEpoch               *int              `json:"packageEpoch,omitempty" yaml:"packageEpoch,omitempty" jsonschema:"title=Package Epoch,description=Epoch of the 'deb' and 'rpm' mode versions (e.g. 1 gives '1:1.2.3'). Default is no epoch"`
+	PackageRevision            *string           `json:"packageRevision,omitempty" yaml:"packageRevision,omitempty" jsonschema:"title=Package Revision,description=Debian revision or RPM release of the 'deb' and 'rpm' mode versions (e.g. '1' gives '1.2.3-1'). Default is no revision"`
+	NpmPrereleaseTag           *string           `json:"npmPrereleaseTag,omitempty" yaml:"npmPrereleaseTag,omitempty" jsonschema:"title=npm Prerelease Tag,description=npm dist-tag of branch prereleases in mode 'npm': 'branch' (default) uses the sanitized branch name or 'next' uses 'next'. Releases always get 'latest',enum=branch,enum=next"`
+	HelmChartTagPrefix         *string           `json:"helmChartTagPrefix,omitempty" yaml:"helmChartTagPrefix,omitempty" jsonschema:"title=Helm Chart Tag Prefix,description=Prefix of the tags the chart version of mode 'helm' is calculated from (e.g. 'chart-'). Default is tagPrefix"`
+	HelmAppVersionTagPrefix    *string           `json:"helmAppVersionTagPrefix,omitempty" yaml:"helmAppVersionTagPrefix,omitempty" jsonschema:"title=Helm App Version Tag Prefix,description=Prefix of the tags the appVersion of mode 'helm' is calculated from (e.g. 'v'). Default is tagPrefix"`
+	HelmChartFile              *string           `json:"helmChartFile,omitempty" yaml:"helmChartFile,omitempty" jsonschema:"title=Helm Chart File,description=Path of a Chart.yaml to write the chart version and appVersion to. Comments and formatting are kept. Default is empty (no file)"`
+	ApplyFiles                 []ApplyFile       `json:"applyFiles,omitempty" yaml:"applyFiles,omitempty" jsonschema:"title=Apply Files,description=Files the apply command writes the version to"`
+	DockerFloatingTags         []string          `json:"dockerFloatingTags,omitempty" yaml:"dockerFloatingTags,omitempty" jsonschema:"title=Docker Floating Tags,description=Floating tags added by docker-tags: 'major' (1) and 'minor' (1.2) and 'latest' for releases and 'branch' (branch-<name>) for prereleases. Default is all of them"`
+	ChangelogCommitURL         *string           `json:"changelogCommitUrl,omitempty" yaml:"changelogCommitUrl,omitempty" jsonschema:"title=Changelog Commit URL,description=Go text/template of the commit links in the changelog (e.g. 'https://github.com/org/repo/commit/{{.SHA}}'). Fields: SHA and ShortSHA. Default is empty (no links)"`
+	ChangelogPullRequestURL    *string           `json:"changelogPullRequestUrl,omitempty" yaml:"changelogPullRequestUrl,omitempty" jsonschema:"title=Changelog Pull Request URL,description=Go text/template of the pull request links in the changelog (e.g. 'https://github.com/org/repo/pull/{{.Number}}'). Default is empty (no links)"`
+	ReleaseTypes               []string          `json:"releaseTypes,omitempty" yaml:"releaseTypes,omitempty" jsonschema:"title=Release Types,description=Conventional Commit types that make should-release release (default: ['feat' 'fix' 'perf' 'revert' 'other']). 'other' stands for commits that aren't Conventional Commits. Breaking changes always release"`
+	ReleasePaths               []string          `json:"releasePaths,omitempty" yaml:"releasePaths,omitempty" jsonschema:"title=Release Paths,description=Glob patterns of the paths whose changes make should-release release (e.g. ['src/**' '!**/*.md']). Patterns starting with '!' exclude paths and patterns without '/' match file names in any directory. Default is all paths"`
+	ReleaseSkipPatterns        []string          `json:"releaseSkipPatterns,omitempty" yaml:"releaseSkipPatterns,omitempty" jsonschema:"title=Release Skip Patterns,description=Regular expressions of commit messages that never make should-release release. Default is ['\\[skip release\\]' '\\[release skip\\]']"`
+}
+
+// ApplyFile is a file the apply command writes the version to
+type ApplyFile struct {
+	Path    string `json:"path" yaml:"path" mapstructure:"path" jsonschema:"title=Path,description=File to write the version to. May be a glob pattern (e.g. 'src/*/*.csproj')"`
+	Type    string `json:"type,omitempty" yaml:"type,omitempty" mapstructure:"type" jsonschema:"title=Type,description=File type. Default is detected from the file name: package.json and pyproject.toml and Cargo.toml and Chart.yaml and *.csproj and pom.xml and VERSION,enum=npm,enum=pyproject,enum=cargo,enum=helm,enum=csproj,enum=pom,enum=text,enum=regex"`
+	Mode    string `json:"mode,omitempty" yaml:"mode,omitempty" mapstructure:"mode" jsonschema:"title=Mode,description=Version format to write instead of the default of the file type (e.g. 'pep440' for a VERSION file read by Python),enum=semver,enum=pep440,enum=maven,enum=deb,enum=rpm"`
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty" mapstructure:"pattern" jsonschema:"title=Pattern,description=Regular expression for type 'regex'. The version replaces the group named 'version' or else the first capture group of every match"`
+}
+
+// FieldKind returns the kind of the setting with the given key (the JSON name, compared without
+// case like viper does), with pointers dereferenced. ok is false for unknown keys.
+func FieldKind(key string) (kind reflect.Kind, ok bool) {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if strings.EqualFold(name, key) {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			return fieldType.Kind(), true
+		}
+	}
+	return reflect.Invalid, false
 }
 
 // GenerateSchema generates a JSON schema for the configuration
@@ -5,10 +5,70 @@ const (
 	// Version-related defaults
 	InitialVersion = "1.0.0"  // Initial version when no tags exist in repository
 	PrereleaseID   = "pre"    // Prerelease identifier for prerelease versions
-	DefaultMode    = "json"   // Default version format mode: "json", "semver" or "pep440"
+	DefaultMode    = "json"   // Default version format mode: "json", "semver", "pep440", "maven", "dotnet", "deb", "rpm", "npm" or "helm"
 	ModeJson       = "json"   // JSON mode constant
 	ModeSemver     = "semver" // Semver mode constant
 	ModePep440     = "pep440" // PEP 440 mode constant
+	ModeMaven      = "maven"  // Maven mode constant
+	ModeDotnet     = "dotnet" // .NET mode constant
+	ModeDeb        = "deb"    // Debian package mode constant
+	ModeRpm        = "rpm"    // RPM package mode constant
+	ModeNpm        = "npm"    // npm package mode constant
+	ModeHelm       = "helm"   // Helm chart mode constant
+
+	// PEP 440 conversion defaults
+	Pep440SegmentAlpha         = "a"   // PEP 440 alpha release segment (1.0.0a1)
+	Pep440SegmentBeta          = "b"   // PEP 440 beta release segment (1.0.0b1)
+	Pep440SegmentRC            = "rc"  // PEP 440 release candidate segment (1.0.0rc1)
+	Pep440SegmentDev           = "dev" // PEP 440 development release segment (1.0.0.dev1)
+	DefaultPep440BranchSegment = "a"   // Default PEP 440 segment for branch prereleases
+	DefaultPep440LocalLabel    = false // Whether to add the branch name as a PEP 440 local version label
+
+	// Maven conversion defaults
+	MavenSnapshotStyleSnapshot  = "snapshot"  // Maven snapshots as 1.2.4-SNAPSHOT
+	MavenSnapshotStyleTimestamp = "timestamp" // Maven snapshots as unique 1.2.4-20260118.153012-3 versions
+	DefaultMavenSnapshotStyle   = "snapshot"  // Default Maven snapshot style
+
+	// .NET conversion defaults
+	DotnetAssemblyVersionMajor   = "major" // AssemblyVersion as Major.0.0.0
+	DotnetAssemblyVersionMinor   = "minor" // AssemblyVersion as Major.Minor.0.0
+	DefaultDotnetAssemblyVersion = "major" // Default AssemblyVersion style, only major versions break binding
+	DotnetNuGetBuildPadding      = 4       // Digits the build number is padded to in NuGet prerelease labels
+	DotnetNuGetMaxPrerelease     = 20      // Maximum length of a NuGet prerelease label for old clients
+
+	// Docker tag defaults
+	DockerFloatingTagMajor  = "major"  // Floating tag with the major version of releases (1)
+	DockerFloatingTagMinor  = "minor"  // Floating tag with the major and minor version of releases (1.2)
+	DockerFloatingTagLatest = "latest" // Floating "latest" tag for releases on the main branch
+	DockerFloatingTagBranch = "branch" // Floating "branch-<name>" tag for prereleases
+
+	// npm defaults
+	NpmDistTagLatest        = "latest" // npm dist-tag of releases
+	NpmDistTagNext          = "next"   // npm dist-tag of prereleases that don't get a branch dist-tag
+	NpmPrereleaseTagBranch  = "branch" // Publish branch prereleases with the sanitized branch name as dist-tag
+	NpmPrereleaseTagNext    = "next"   // Publish all prereleases with the "next" dist-tag
+	DefaultNpmPrereleaseTag = "branch" // Default dist-tag style of branch prereleases
+	NpmMaxVersionLength     = 256      // Maximum length of an npm package version (node-semver MAX_LENGTH)
+
+	// apply file types
+	ApplyTypeNpm       = "npm"       // package.json, npm version
+	ApplyTypePyproject = "pyproject" // pyproject.toml, PEP 440 version of [project] or [tool.poetry]
+	ApplyTypeCargo     = "cargo"     // Cargo.toml, semver of [package] or [workspace.package]
+	ApplyTypeHelm      = "helm"      // Chart.yaml, chart version and appVersion
+	ApplyTypeCsproj    = "csproj"    // .NET project file, .NET versions
+	ApplyTypePom       = "pom"       // pom.xml, Maven version of the project
+	ApplyTypeText      = "text"      // File that contains only the version, e.g. VERSION
+	ApplyTypeRegex     = "regex"     // Any file, the version replaces a capture group of a regular expression
+
+	// bump increments
+	BumpMajor                  = "major"      // Next major version, 1.2.3 -> 2.0.0
+	BumpMinor                  = "minor"      // Next minor version, 1.2.3 -> 1.3.0
+	BumpPatch                  = "patch"      // Next patch version, 1.2.3 -> 1.2.4
+	BumpPrerelease             = "prerelease" // Next prerelease, 1.2.3 -> 1.2.4-rc.1 and 1.2.4-rc.1 -> 1.2.4-rc.2
+	DefaultBumpPrereleaseLabel = "rc"         // Prerelease label of bumps from a release version
+
+	// should-release defaults
+	ReleaseTypeOther = "other" // Release type of commits that aren't Conventional Commits
 
 	// Branch-related defaults
 	MainBranchBehavior       = "release" // Default behavior for main branch: "release" or "pre"
@@ -20,6 +80,17 @@ const (
 	DefaultOutdatedCheckMode = "tagged"  // Default mode for outdated base check: "tagged" or "all"
 	OutdatedCheckModeTagged  = "tagged"  // Check mode: only warn on new tags
 	OutdatedCheckModeAll     = "all"     // Check mode: warn on any new commits
+	DefaultRemote            = "origin"  // Default remote used to look up remote-tracking branches
+	DefaultMainBranchSource  = "local"   // Default source for the main branch: "local" or "remote"
+	MainBranchSourceLocal    = "local"   // Main branch source: local branch first, then the remote
+	MainBranchSourceRemote   = "remote"  // Main branch source: remote branch first, then the local branch
+	DefaultFailOnDivergence  = false     // Whether to fail (vs warn) when local and remote main have diverged
+
+	// Output-related defaults
+	DefaultGitHubActionsOutput = true                     // Whether to write step outputs, env and summary when running in GitHub Actions
+	DefaultDotenvFile          = "autoversion.env"        // Dotenv report file written for GitLab CI
+	DefaultPropertiesFile      = "autoversion.properties" // Properties file written for Jenkins
+	DefaultVariablePrefix      = "AUTOVERSION_"           // Prefix of the variable names the version is exported as
 )
 
 // Branch name prefixes that are automatically stripped during sanitization
@@ -37,7 +108,53 @@ var MainBranches = []string{"main", "master"}
 var ValidMainBranchBehaviors = []string{"release", "pre"}
 
 // ValidModes are the allowed values for version mode
-var ValidModes = []string{ModeJson, ModeSemver, ModePep440}
+var ValidModes = []string{ModeJson, ModeSemver, ModePep440, ModeMaven, ModeDotnet, ModeDeb, ModeRpm, ModeNpm, ModeHelm}
+
+// ValidMavenSnapshotStyles are the allowed values for the Maven snapshot style
+var ValidMavenSnapshotStyles = []string{MavenSnapshotStyleSnapshot, MavenSnapshotStyleTimestamp}
+
+// ValidDotnetAssemblyVersions are the allowed values for the .NET AssemblyVersion style
+var ValidDotnetAssemblyVersions = []string{DotnetAssemblyVersionMajor, DotnetAssemblyVersionMinor}
+
+// ValidApplyTypes are the allowed file types of apply files
+var ValidApplyTypes = []string{ApplyTypeNpm, ApplyTypePyproject, ApplyTypeCargo, ApplyTypeHelm, ApplyTypeCsproj, ApplyTypePom, ApplyTypeText, ApplyTypeRegex}
+
+// ValidBumpParts are the allowed increments of the bump command
+var ValidBumpParts = []string{BumpMajor, BumpMinor, BumpPatch, BumpPrerelease}
+
+// DefaultReleaseTypes are the Conventional Commit types that make should-release release
+var DefaultReleaseTypes = []string{"feat", "fix", "perf", "revert", ReleaseTypeOther}
+
+// DefaultReleaseSkipPatterns are the commit message patterns that never make should-release release
+var DefaultReleaseSkipPatterns = []string{`\[skip release\]`, `\[release skip\]`}
+
+// ValidNpmPrereleaseTags are the allowed values for the npm dist-tag style of branch prereleases
+var ValidNpmPrereleaseTags = []string{NpmPrereleaseTagBranch, NpmPrereleaseTagNext}
+
+// DefaultDockerFloatingTags are the floating Docker tags enabled by default
+var DefaultDockerFloatingTags = []string{DockerFloatingTagMajor, DockerFloatingTagMinor, DockerFloatingTagLatest, DockerFloatingTagBranch}
+
+// ValidDockerFloatingTags are the allowed floating Docker tags
+var ValidDockerFloatingTags = []string{DockerFloatingTagMajor, DockerFloatingTagMinor, DockerFloatingTagLatest, DockerFloatingTagBranch}
+
+// ValidPep440Segments are the allowed PEP 440 segments for prerelease labels
+var ValidPep440Segments = []string{Pep440SegmentAlpha, Pep440SegmentBeta, Pep440SegmentRC, Pep440SegmentDev}
+
+// Pep440Labels are the semver prerelease labels that map to their own PEP 440 segment
+// Any other label is a branch name and uses the branch segment.
+var Pep440Labels = map[string]string{
+	PrereleaseID: Pep440SegmentAlpha,
+	"alpha":      Pep440SegmentAlpha,
+	"a":          Pep440SegmentAlpha,
+	"beta":       Pep440SegmentBeta,
+	"b":          Pep440SegmentBeta,
+	"rc":         Pep440SegmentRC,
+	"c":          Pep440SegmentRC,
+	"dev":        Pep440SegmentDev,
+}
+
+// ValidMainBranchSources are the allowed values for the main branch source
+var ValidMainBranchSources = []string{MainBranchSourceLocal, MainBranchSourceRemote}
 
 // ValidOutdatedCheckModes are the allowed values for outdated base check mode
 var ValidOutdatedCheckModes = []string{OutdatedCheckModeTagged, OutdatedCheckModeAll}
@@ -45,6 +162,7 @@ var ValidOutdatedCheckModes = []string{OutdatedCheckModeTagged, OutdatedCheckMod
 // CIProvider represents configuration for a specific CI provider
 type CIProvider struct {
 	BranchEnvVar string
+	DetectEnvVar string // environment variable that is always set when running in this CI provider
 }
 
 // WellKnownCIProviders contains default configurations for well-known CI providers
@@ -52,20 +170,29 @@ type CIProvider struct {
 var WellKnownCIProviders = map[string]*CIProvider{
 	"github-actions": {
 		BranchEnvVar: "GITHUB_HEAD_REF",
+		DetectEnvVar: "GITHUB_ACTIONS",
 	},
 	"gitlab-ci": {
 		BranchEnvVar: "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME",
+		DetectEnvVar: "GITLAB_CI",
 	},
 	"circleci": {
 		BranchEnvVar: "CIRCLE_BRANCH",
+		DetectEnvVar: "CIRCLECI",
 	},
 	"travis-ci": {
 		BranchEnvVar: "TRAVIS_PULL_REQUEST_BRANCH",
+		DetectEnvVar: "TRAVIS",
 	},
 	"jenkins": {
 		BranchEnvVar: "CHANGE_BRANCH",
+		DetectEnvVar: "JENKINS_URL",
 	},
 	"azure-pipelines": {
 		BranchEnvVar: "SYSTEM_PULLREQUEST_SOURCEBRANCH",
+		DetectEnvVar: "TF_BUILD",
+	},
+	"teamcity": {
+		DetectEnvVar: "TEAMCITY_VERSION",
 	},
 }
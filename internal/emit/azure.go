@@ -0,0 +1,43 @@
+package emit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// AzurePipelines publishes the version with Azure Pipelines logging commands
+type AzurePipelines struct {
+	Out    io.Writer
+	Prefix string // prefix of the variable names
+}
+
+// Provider returns the CI provider the writer is for
+func (a *AzurePipelines) Provider() string {
+	return "azure-pipelines"
+}
+
+// Write sets every variable for the following steps and as an output variable of the step
+// (isOutput=true, referenced as <step>.<name> from other jobs), and sets the build number to the
+// version with the configured prefix
+func (a *AzurePipelines) Write(output string, result *version.Result) error {
+	var b strings.Builder
+	for _, v := range variables(a.Prefix, output, result) {
+		value := escapeAzureData(v.Value)
+		fmt.Fprintf(&b, "##vso[task.setvariable variable=%s]%s\n", v.Name, value)
+		fmt.Fprintf(&b, "##vso[task.setvariable variable=%s;isoutput=true]%s\n", v.Name, value)
+	}
+	fmt.Fprintf(&b, "##vso[build.updatebuildnumber]%s\n", escapeAzureData(result.SemverWithPrefix))
+
+	if _, err := io.WriteString(a.Out, b.String()); err != nil {
+		return fmt.Errorf("failed to write Azure Pipelines logging commands: %w", err)
+	}
+	return nil
+}
+
+// escapeAzureData escapes the data part of a logging command so it stays on one line
+func escapeAzureData(value string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
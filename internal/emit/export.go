@@ -0,0 +1,76 @@
+package emit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// ExportFormats are the formats the JSON output fields can be printed as variable assignments in
+var ExportFormats = []string{"env", "dotenv", "make", "powershell"}
+
+// exporters format a single variable assignment for each export format
+var exporters = map[string]func(name, value string) (string, error){
+	"env":        exportShell,
+	"dotenv":     exportDotenv,
+	"make":       exportMake,
+	"powershell": exportPowerShell,
+}
+
+// Export prints every field of the JSON output as a variable assignment, one per line
+// Names are the prefix followed by the field name in upper snake case (e.g. AUTOVERSION_SEMVER_WITH_PREFIX).
+//   - env: POSIX shell exports, for eval "$(autoversion --output env)"
+//   - dotenv: NAME=value lines, quoted only when needed
+//   - make: NAME := value lines, for include version.mk
+//   - powershell: $env:NAME = 'value' lines, for Invoke-Expression
+func Export(format, prefix string, result *version.Result) (string, error) {
+	export, ok := exporters[format]
+	if !ok {
+		return "", fmt.Errorf("invalid output format '%s': must be one of %v", format, ExportFormats)
+	}
+
+	var b strings.Builder
+	for _, v := range fieldVariables(prefix, result) {
+		line, err := export(v.Name, v.Value)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// exportShell single-quotes the value, the only character that needs escaping is the single quote
+func exportShell(name, value string) (string, error) {
+	return fmt.Sprintf("export %s='%s'", name, strings.ReplaceAll(value, "'", `'\''`)), nil
+}
+
+// dotenvSafe matches values that can be written to a dotenv file without quotes
+var dotenvSafe = regexp.MustCompile(`^[A-Za-z0-9_.+/:@-]*$`)
+
+// exportDotenv leaves simple values unquoted and double-quotes everything else
+func exportDotenv(name, value string) (string, error) {
+	if dotenvSafe.MatchString(value) {
+		return name + "=" + value, nil
+	}
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`).Replace(value)
+	return fmt.Sprintf(`%s="%s"`, name, value), nil
+}
+
+// exportMake escapes the characters make expands or treats as a comment
+// Make has no way to write a newline in a simple assignment, so multiline values are an error.
+func exportMake(name, value string) (string, error) {
+	if err := singleLine(name, value); err != nil {
+		return "", err
+	}
+	value = strings.NewReplacer("$", "$$", "#", `\#`).Replace(value)
+	return fmt.Sprintf("%s := %s", name, value), nil
+}
+
+// exportPowerShell single-quotes the value, single quotes are escaped by doubling them
+func exportPowerShell(name, value string) (string, error) {
+	return fmt.Sprintf("$env:%s = '%s'", name, strings.ReplaceAll(value, "'", "''")), nil
+}
@@ -0,0 +1,140 @@
+package emit
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+)
+
+func TestExport(t *testing.T) {
+	result := testResult()
+	result.Branch = "feature/it's $HOME #1"
+
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{
+			format: "env",
+			expected: []string{
+				"export AUTOVERSION_SEMVER='1.2.4-login.3'",
+				"export AUTOVERSION_MAJOR='1'",
+				`export AUTOVERSION_BRANCH='feature/it'\''s $HOME #1'`,
+			},
+		},
+		{
+			format: "dotenv",
+			expected: []string{
+				"AUTOVERSION_SEMVER=1.2.4-login.3",
+				"AUTOVERSION_IS_RELEASE=false",
+				`AUTOVERSION_BRANCH="feature/it's \$HOME #1"`,
+			},
+		},
+		{
+			format: "make",
+			expected: []string{
+				"AUTOVERSION_SEMVER := 1.2.4-login.3",
+				`AUTOVERSION_BRANCH := feature/it's $$HOME \#1`,
+			},
+		},
+		{
+			format: "powershell",
+			expected: []string{
+				"$env:AUTOVERSION_SEMVER = '1.2.4-login.3'",
+				"$env:AUTOVERSION_BRANCH = 'feature/it''s $HOME #1'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output, err := Export(tt.format, "AUTOVERSION_", result)
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+			for _, expected := range tt.expected {
+				found := false
+				for _, line := range lines {
+					if line == expected {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("Expected line %q in output:\n%s", expected, output)
+				}
+			}
+			if strings.Contains(output, "AUTOVERSION_VERSION") {
+				t.Errorf("Expected only the JSON output fields, got:\n%s", output)
+			}
+		})
+	}
+
+	if _, err := Export("xml", "AUTOVERSION_", result); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+
+	result.Branch = "two\nlines"
+	if _, err := Export("make", "AUTOVERSION_", result); err == nil {
+		t.Errorf("Expected error for multiline value in make format")
+	}
+}
+
+func TestExportEnvEval(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	result := testResult()
+	result.Branch = "feature/it's \"$HOME\" `id`\nline two"
+	output, err := Export("env", "V_", result)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	out, err := exec.Command(sh, "-c", output+`printf '%s' "$V_BRANCH"`).Output()
+	if err != nil {
+		t.Fatalf("Failed to evaluate output: %v", err)
+	}
+	if string(out) != result.Branch {
+		t.Errorf("Expected %q after eval, got %q", result.Branch, string(out))
+	}
+}
+
+func TestVariablePrefix(t *testing.T) {
+	tests := []struct {
+		prefix   *string
+		expected string
+		wantErr  bool
+	}{
+		{nil, "AUTOVERSION_", false},
+		{stringPtr(""), "", false},
+		{stringPtr("APP_"), "APP_", false},
+		{stringPtr("1APP"), "", true},
+		{stringPtr("APP-"), "", true},
+	}
+
+	for _, tt := range tests {
+		cfg := &config.Config{VariablePrefix: tt.prefix}
+		prefix, err := VariablePrefix(cfg)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for prefix %q", *tt.prefix)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if prefix != tt.expected {
+			t.Errorf("Expected prefix %q, got %q", tt.expected, prefix)
+		}
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
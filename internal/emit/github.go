@@ -0,0 +1,174 @@
+package emit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// GitHubActions writes the version to the files GitHub Actions reads step outputs, environment
+// variables for later steps and the job summary from
+type GitHubActions struct {
+	OutputPath  string // $GITHUB_OUTPUT
+	EnvPath     string // $GITHUB_ENV
+	SummaryPath string // $GITHUB_STEP_SUMMARY
+	Prefix      string // prefix of the environment variable names
+}
+
+// githubEnvFields are the output fields that are also exported as environment variables
+var githubEnvFields = []string{"semver", "semverWithPrefix", "pep440", "pep440WithPrefix", "isRelease"}
+
+// DetectGitHubActions returns a writer for the GitHub Actions file paths when running in GitHub Actions
+func DetectGitHubActions(prefix string) (*GitHubActions, bool) {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil, false
+	}
+	return NewGitHubActions(prefix), true
+}
+
+// NewGitHubActions returns a GitHub Actions writer for the file paths in the environment
+func NewGitHubActions(prefix string) *GitHubActions {
+	return &GitHubActions{
+		OutputPath:  os.Getenv("GITHUB_OUTPUT"),
+		EnvPath:     os.Getenv("GITHUB_ENV"),
+		SummaryPath: os.Getenv("GITHUB_STEP_SUMMARY"),
+		Prefix:      prefix,
+	}
+}
+
+// Provider returns the CI provider the writer is for
+func (g *GitHubActions) Provider() string {
+	return "github-actions"
+}
+
+// Write sets the formatted version as the "version" step output and every JSON output field as a
+// step output of the same name, except "prerelease" and "is-prerelease", which keep the meaning
+// they had in the original action entrypoint (see legacyPrerelease). The version and a few of the
+// fields are exported as environment variables (AUTOVERSION_VERSION, AUTOVERSION_SEMVER, ... with
+// the default prefix), and all fields are added to the job summary as a markdown table.
+// Files whose path is empty are skipped.
+func (g *GitHubActions) Write(output string, result *version.Result) error {
+	fields := version.OutputFields(result)
+
+	if g.OutputPath != "" {
+		var b strings.Builder
+		writeGitHubVariable(&b, "version", output)
+		for _, field := range fields {
+			if field.Name == "prerelease" {
+				continue
+			}
+			writeGitHubVariable(&b, field.Name, field.Value)
+		}
+		prerelease, isPrerelease := legacyPrerelease(output)
+		writeGitHubVariable(&b, "prerelease", prerelease)
+		writeGitHubVariable(&b, "is-prerelease", strconv.FormatBool(isPrerelease))
+		if err := appendFile(g.OutputPath, b.String()); err != nil {
+			return fmt.Errorf("failed to write GitHub Actions outputs: %w", err)
+		}
+	}
+
+	if g.EnvPath != "" {
+		var b strings.Builder
+		writeGitHubVariable(&b, g.Prefix+"VERSION", output)
+		for _, field := range fields {
+			for _, name := range githubEnvFields {
+				if field.Name == name {
+					writeGitHubVariable(&b, envName(g.Prefix, field.Name), field.Value)
+				}
+			}
+		}
+		if err := appendFile(g.EnvPath, b.String()); err != nil {
+			return fmt.Errorf("failed to write GitHub Actions environment: %w", err)
+		}
+	}
+
+	if g.SummaryPath != "" {
+		if err := appendFile(g.SummaryPath, githubSummary(result, fields)); err != nil {
+			return fmt.Errorf("failed to write GitHub Actions step summary: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// legacyPrerelease returns the "prerelease" and "is-prerelease" step outputs the way the original
+// action entrypoint parsed them from the printed version: everything after the first "-" (e.g.
+// "feature.1" or "rc.1"), and whether there is a "-" at all. Workflows depend on these values, so
+// they don't follow the prerelease and isRelease fields of the JSON output.
+func legacyPrerelease(output string) (string, bool) {
+	_, prerelease, found := strings.Cut(output, "-")
+	return prerelease, found
+}
+
+// writeGitHubVariable writes a name/value pair in the multiline-safe syntax of GitHub's
+// environment files, with a random delimiter that doesn't appear in the value:
+//
+//	name<<ghadelimiter_<random>
+//	value
+//	ghadelimiter_<random>
+func writeGitHubVariable(b *strings.Builder, name, value string) {
+	delimiter := randomDelimiter()
+	for strings.Contains(value, delimiter) {
+		delimiter = randomDelimiter()
+	}
+	fmt.Fprintf(b, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter)
+}
+
+func randomDelimiter() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("failed to generate random delimiter: %v", err))
+	}
+	return "ghadelimiter_" + hex.EncodeToString(buf)
+}
+
+// githubSummary returns the markdown job summary for a result
+func githubSummary(result *version.Result, fields []version.OutputField) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Version `%s`\n\n", result.SemverWithPrefix)
+	b.WriteString("| Field | Value |\n")
+	b.WriteString("| --- | --- |\n")
+	for _, field := range fields {
+		value := "`" + strings.ReplaceAll(field.Value, "|", "\\|") + "`"
+		if field.Value == "" {
+			value = ""
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", field.Name, value)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// envName converts an output field name to an environment variable name, e.g.
+// "semverWithPrefix" with prefix "AUTOVERSION_" becomes "AUTOVERSION_SEMVER_WITH_PREFIX"
+func envName(prefix, field string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	runes := []rune(field)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(runes[i-1]) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// appendFile appends content to a file, creating it if needed
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
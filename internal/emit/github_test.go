@@ -0,0 +1,226 @@
+package emit
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+func testResult() *version.Result {
+	return &version.Result{
+		Semver:           "1.2.4-login.3",
+		SemverWithPrefix: "v1.2.4-login.3",
+		Pep440:           "1.2.4a3",
+		Pep440WithPrefix: "v1.2.4a3",
+		Major:            1,
+		Minor:            2,
+		Patch:            4,
+		Prerelease:       "login",
+		Build:            3,
+		Branch:           "feature/login",
+		SanitizedBranch:  "login",
+		SHA:              "0123456789abcdef0123456789abcdef01234567",
+		BaseTag:          "v1.2.3",
+		CommitsSinceTag:  3,
+	}
+}
+
+// parseGitHubFile parses a GitHub Actions environment file written with the delimiter syntax
+func parseGitHubFile(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+
+	values := make(map[string]string)
+	header := regexp.MustCompile(`^([A-Za-z0-9_-]+)<<(ghadelimiter_[0-9a-f]{32})$`)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		match := header.FindStringSubmatch(lines[i])
+		if match == nil {
+			t.Fatalf("Unexpected line %d in %s: %q", i+1, path, lines[i])
+		}
+		var valueLines []string
+		for i++; i < len(lines) && lines[i] != match[2]; i++ {
+			valueLines = append(valueLines, lines[i])
+		}
+		if i == len(lines) {
+			t.Fatalf("Missing closing delimiter for %s in %s", match[1], path)
+		}
+		values[match[1]] = strings.Join(valueLines, "\n")
+	}
+	return values
+}
+
+func TestGitHubActionsWrite(t *testing.T) {
+	dir := t.TempDir()
+	gh := &GitHubActions{
+		OutputPath:  filepath.Join(dir, "output"),
+		EnvPath:     filepath.Join(dir, "env"),
+		SummaryPath: filepath.Join(dir, "summary"),
+		Prefix:      "AUTOVERSION_",
+	}
+
+	// Existing content must be kept, the files are shared by all steps
+	if err := os.WriteFile(gh.OutputPath, []byte("other<<EOF\nvalue\nEOF\n"), 0644); err != nil {
+		t.Fatalf("Failed to write output file: %v", err)
+	}
+
+	output := "line one\nline two"
+	if err := gh.Write(output, testResult()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	content, err := os.ReadFile(gh.OutputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "other<<EOF\nvalue\nEOF\n") {
+		t.Errorf("Existing outputs were not preserved:\n%s", content)
+	}
+	if err := os.WriteFile(gh.OutputPath, []byte(strings.TrimPrefix(string(content), "other<<EOF\nvalue\nEOF\n")), 0644); err != nil {
+		t.Fatalf("Failed to rewrite output file: %v", err)
+	}
+
+	outputs := parseGitHubFile(t, gh.OutputPath)
+	expectedOutputs := map[string]string{
+		"version":         output,
+		"semver":          "1.2.4-login.3",
+		"pep440":          "1.2.4a3",
+		"major":           "1",
+		"isRelease":       "false",
+		"prerelease":      "",
+		"build":           "3",
+		"branch":          "feature/login",
+		"sha":             "0123456789abcdef0123456789abcdef01234567",
+		"baseTag":         "v1.2.3",
+		"commitsSinceTag": "3",
+		"schemaVersion":   "1",
+		"is-prerelease":   "false",
+	}
+	for name, expected := range expectedOutputs {
+		if outputs[name] != expected {
+			t.Errorf("Output %s: expected %q, got %q", name, expected, outputs[name])
+		}
+	}
+	if _, ok := outputs["mainBranchDivergenceAhead"]; ok {
+		t.Errorf("Unset nested fields should not be written")
+	}
+
+	env := parseGitHubFile(t, gh.EnvPath)
+	expectedEnv := map[string]string{
+		"AUTOVERSION_VERSION":            output,
+		"AUTOVERSION_SEMVER":             "1.2.4-login.3",
+		"AUTOVERSION_SEMVER_WITH_PREFIX": "v1.2.4-login.3",
+		"AUTOVERSION_PEP440":             "1.2.4a3",
+		"AUTOVERSION_PEP440_WITH_PREFIX": "v1.2.4a3",
+		"AUTOVERSION_IS_RELEASE":         "false",
+	}
+	if len(env) != len(expectedEnv) {
+		t.Errorf("Expected %d environment variables, got %v", len(expectedEnv), env)
+	}
+	for name, expected := range expectedEnv {
+		if env[name] != expected {
+			t.Errorf("Env %s: expected %q, got %q", name, expected, env[name])
+		}
+	}
+
+	summary, err := os.ReadFile(gh.SummaryPath)
+	if err != nil {
+		t.Fatalf("Failed to read summary file: %v", err)
+	}
+	for _, expected := range []string{"### Version `v1.2.4-login.3`", "| Field | Value |", "| branch | `feature/login` |", "| outdatedBase | `false` |"} {
+		if !strings.Contains(string(summary), expected) {
+			t.Errorf("Summary is missing %q:\n%s", expected, summary)
+		}
+	}
+}
+
+func TestGitHubActionsWriteLegacyOutputs(t *testing.T) {
+	// "prerelease" and "is-prerelease" are parsed from the printed version, like the original
+	// action entrypoint did
+	tagged := &version.Result{Semver: "1.3.0-rc.1", Major: 1, Minor: 3, Prerelease: "rc", Build: 1, IsRelease: true}
+	release := &version.Result{Semver: "1.3.0", Major: 1, Minor: 3, IsRelease: true}
+	tests := []struct {
+		output       string
+		result       *version.Result
+		prerelease   string
+		isPrerelease string
+	}{
+		{"1.2.4-login.3", testResult(), "login.3", "true"},
+		{"1.0.0-pre.0", &version.Result{Semver: "1.0.0-pre.0", Prerelease: "pre"}, "pre.0", "true"},
+		{"1.3.0-rc.1", tagged, "rc.1", "true"},
+		{"1.3.0", release, "", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			gh := &GitHubActions{OutputPath: filepath.Join(t.TempDir(), "output")}
+			if err := gh.Write(tt.output, tt.result); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			outputs := parseGitHubFile(t, gh.OutputPath)
+			if outputs["version"] != tt.output || outputs["prerelease"] != tt.prerelease || outputs["is-prerelease"] != tt.isPrerelease {
+				t.Errorf("Expected version=%q prerelease=%q is-prerelease=%q, got version=%q prerelease=%q is-prerelease=%q",
+					tt.output, tt.prerelease, tt.isPrerelease, outputs["version"], outputs["prerelease"], outputs["is-prerelease"])
+			}
+		})
+	}
+}
+
+func TestGitHubActionsWriteSkipsEmptyPaths(t *testing.T) {
+	dir := t.TempDir()
+	gh := &GitHubActions{OutputPath: filepath.Join(dir, "output")}
+	if err := gh.Write("1.2.4-login.3", testResult()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the output file to be written, got %d files", len(entries))
+	}
+}
+
+func TestDetectGitHubActions(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	if _, ok := DetectGitHubActions("AUTOVERSION_"); ok {
+		t.Errorf("Expected GitHub Actions not to be detected")
+	}
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_OUTPUT", "/tmp/output")
+	t.Setenv("GITHUB_ENV", "/tmp/env")
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	gh, ok := DetectGitHubActions("AUTOVERSION_")
+	if !ok {
+		t.Fatalf("Expected GitHub Actions to be detected")
+	}
+	if gh.OutputPath != "/tmp/output" || gh.EnvPath != "/tmp/env" || gh.SummaryPath != "" {
+		t.Errorf("Unexpected paths: %+v", gh)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"semver":                    "AUTOVERSION_SEMVER",
+		"semverWithPrefix":          "AUTOVERSION_SEMVER_WITH_PREFIX",
+		"pep440WithPrefix":          "AUTOVERSION_PEP440_WITH_PREFIX",
+		"sha":                       "AUTOVERSION_SHA",
+		"ciBranchUsed":              "AUTOVERSION_CI_BRANCH_USED",
+		"mainBranchDivergenceAhead": "AUTOVERSION_MAIN_BRANCH_DIVERGENCE_AHEAD",
+	}
+	for field, expected := range tests {
+		if got := envName("AUTOVERSION_", field); got != expected {
+			t.Errorf("envName(%q) = %q, expected %q", field, got, expected)
+		}
+	}
+}
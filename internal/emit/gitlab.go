@@ -0,0 +1,44 @@
+package emit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// GitLab writes the version to a dotenv report file, which GitLab CI turns into variables for
+// later jobs when the job declares it as an artifacts:reports:dotenv report
+type GitLab struct {
+	Path   string
+	Prefix string // prefix of the variable names
+}
+
+// NewGitLab returns a GitLab writer for the default dotenv file
+func NewGitLab(prefix string) *GitLab {
+	return &GitLab{Path: defaults.DefaultDotenvFile, Prefix: prefix}
+}
+
+// Provider returns the CI provider the writer is for
+func (g *GitLab) Provider() string {
+	return "gitlab-ci"
+}
+
+// Write writes every variable to the dotenv file as NAME=value, replacing the file
+// GitLab doesn't support multiline values in dotenv reports.
+func (g *GitLab) Write(output string, result *version.Result) error {
+	var b strings.Builder
+	for _, v := range variables(g.Prefix, output, result) {
+		if err := singleLine(v.Name, v.Value); err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s=%s\n", v.Name, v.Value)
+	}
+
+	if err := os.WriteFile(g.Path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write GitLab dotenv file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package emit
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// goFieldAliases map common Go variable names to the field they are set to by GoLdflags
+var goFieldAliases = map[string]string{
+	"version":     "semverWithPrefix",
+	"commit":      "sha",
+	"gitcommit":   "sha",
+	"revision":    "sha",
+	"shortcommit": "shortSha",
+	"date":        "commitTime",
+	"builddate":   "commitTime",
+	"commitdate":  "commitTime",
+}
+
+// goWord matches the words of a camelCase field name
+var goWord = regexp.MustCompile(`[A-Z]?[a-z0-9]+|[A-Z]+`)
+
+// goInitialisms are the words written in upper case in Go names
+var goInitialisms = map[string]bool{"sha": true, "ci": true}
+
+// GoLdflags returns -X linker flags that set Go string variables to version fields
+// Each var is "importpath.name" or "importpath.name=field". Without a field, the variable name picks
+// it: a field of the same name (main.Semver, main.Branch), or version (semverWithPrefix), commit and
+// revision (sha), shortCommit (shortSha) and date (commitTime).
+func GoLdflags(vars []string, result *version.Result) (string, error) {
+	if len(vars) == 0 {
+		return "", fmt.Errorf("no variables given, use --var importpath.name[=field] (e.g. main.Version)")
+	}
+	fields := goFields(result)
+
+	var flags []string
+	for _, v := range vars {
+		symbol, field, hasField := strings.Cut(v, "=")
+		lastDot := strings.LastIndex(symbol, ".")
+		if lastDot <= 0 || !token.IsIdentifier(symbol[lastDot+1:]) {
+			return "", fmt.Errorf("invalid variable '%s': must be importpath.name (e.g. main.Version)", symbol)
+		}
+		if !hasField {
+			field = symbol[lastDot+1:]
+			if alias, ok := goFieldAliases[strings.ToLower(field)]; ok {
+				field = alias
+			}
+		}
+
+		value, ok := "", false
+		for _, f := range fields {
+			if strings.EqualFold(f.Name, field) {
+				value, ok = f.Value, true
+				break
+			}
+		}
+		if !ok {
+			return "", fmt.Errorf("no version field for variable '%s': use %s=field with one of %s", symbol, symbol, goFieldNames(fields))
+		}
+		arg, err := goLdflagQuote(symbol + "=" + value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for variable '%s': %w", symbol, err)
+		}
+		flags = append(flags, "-X "+arg)
+	}
+	return strings.Join(flags, " "), nil
+}
+
+// GoSource returns a Go file that declares a constant for every version field
+func GoSource(pkg string, result *version.Result) (string, error) {
+	if !token.IsIdentifier(pkg) {
+		return "", fmt.Errorf("invalid package name '%s'", pkg)
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by autoversion go generate. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("// Version information of the commit this file was generated for\n")
+	b.WriteString("const (\n")
+	for _, field := range goFields(result) {
+		value := field.Value
+		if field.Type == "string" {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, "%s = %s\n", goName(field.Name), value)
+	}
+	b.WriteString(")\n")
+
+	source, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format Go source: %w", err)
+	}
+	return string(source), nil
+}
+
+// goFields returns the version fields available to Go programs: the fields of the JSON output
+// without schemaVersion, the short SHA and the commit time
+func goFields(result *version.Result) []version.OutputField {
+	var fields []version.OutputField
+	for _, field := range version.OutputFields(result) {
+		if field.Name != "schemaVersion" {
+			fields = append(fields, field)
+		}
+	}
+	commitTime := ""
+	if !result.CommitTime.IsZero() {
+		commitTime = result.CommitTime.UTC().Format(time.RFC3339)
+	}
+	return append(fields,
+		version.OutputField{Name: "shortSha", Value: result.ShortSHA, Type: "string"},
+		version.OutputField{Name: "commitTime", Value: commitTime, Type: "string"},
+	)
+}
+
+// goFieldNames returns the sorted field names, for error messages
+func goFieldNames(fields []version.OutputField) string {
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// goName converts a camelCase field name to an exported Go name (e.g. "shortSha" -> "ShortSHA")
+func goName(name string) string {
+	words := goWord.FindAllString(name, -1)
+	for i, word := range words {
+		if goInitialisms[strings.ToLower(word)] {
+			words[i] = strings.ToUpper(word)
+		} else {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// goLdflagQuote quotes a -X argument if it contains spaces or quotes, the way the go command splits
+// -ldflags. The go command has no escapes, so an argument with both single and double quotes can't
+// be quoted.
+func goLdflagQuote(arg string) (string, error) {
+	if !strings.ContainsAny(arg, " \t\n\r'\"") {
+		return arg, nil
+	}
+	if !strings.Contains(arg, "'") {
+		return "'" + arg + "'", nil
+	}
+	if !strings.Contains(arg, `"`) {
+		return `"` + arg + `"`, nil
+	}
+	return "", fmt.Errorf("%s contains both single and double quotes and cannot be quoted", arg)
+}
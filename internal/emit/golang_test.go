@@ -0,0 +1,108 @@
+package emit
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoLdflags(t *testing.T) {
+	result := testResult()
+	result.ShortSHA = "0123456"
+	result.CommitTime = time.Date(2026, 1, 18, 15, 30, 12, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name     string
+		vars     []string
+		expected string
+	}{
+		{"version and commit", []string{"main.Version", "main.Commit"}, "-X main.Version=v1.2.4-login.3 -X main.Commit=0123456789abcdef0123456789abcdef01234567"},
+		{"field names", []string{"main.semver", "main.ShortSHA", "main.Date"}, "-X main.semver=1.2.4-login.3 -X main.ShortSHA=0123456 -X main.Date=2026-01-18T14:30:12Z"},
+		{"explicit field", []string{"github.com/org/app/internal/build.Label=prerelease"}, "-X github.com/org/app/internal/build.Label=login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ldflags, err := GoLdflags(tt.vars, result)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ldflags != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, ldflags)
+			}
+		})
+	}
+
+	result.Branch = "feature/it's a branch"
+	ldflags, err := GoLdflags([]string{"main.Branch"}, result)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ldflags != `-X "main.Branch=feature/it's a branch"` {
+		t.Errorf("Expected a quoted flag, got %s", ldflags)
+	}
+
+	// The go command has no escapes for a value with both kinds of quotes
+	result.Branch = `feature/it's "quoted"`
+	if _, err := GoLdflags([]string{"main.Branch"}, result); err == nil || !strings.Contains(err.Error(), "cannot be quoted") {
+		t.Errorf("Expected an error for a value with both kinds of quotes, got %v", err)
+	}
+
+	for _, vars := range [][]string{nil, {"Version"}, {"main.Unknown"}, {"main.Version=unknown"}} {
+		if _, err := GoLdflags(vars, result); err == nil {
+			t.Errorf("Expected error for %v", vars)
+		}
+	}
+}
+
+func TestGoSource(t *testing.T) {
+	result := testResult()
+	result.ShortSHA = "0123456"
+
+	source, err := GoSource("version", result)
+	if err != nil {
+		t.Fatalf("GoSource failed: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "version_gen.go", source, 0); err != nil {
+		t.Fatalf("Generated source doesn't parse: %v\n%s", err, source)
+	}
+	for _, expected := range []string{
+		"// Code generated by autoversion go generate. DO NOT EDIT.",
+		"package version",
+		`Semver           = "1.2.4-login.3"`,
+		"Major            = 1",
+		"IsRelease        = false",
+		`SHA              = "0123456789abcdef0123456789abcdef01234567"`,
+		`ShortSHA         = "0123456"`,
+		`CIBranchUsed     = false`,
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("Expected %q in generated source:\n%s", expected, source)
+		}
+	}
+	if strings.Contains(source, "SchemaVersion") {
+		t.Errorf("Expected no SchemaVersion constant:\n%s", source)
+	}
+
+	if _, err := GoSource("my-package", result); err == nil {
+		t.Errorf("Expected error for an invalid package name")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"semver":                       "Semver",
+		"pep440WithPrefix":             "Pep440WithPrefix",
+		"sha":                          "SHA",
+		"shortSha":                     "ShortSHA",
+		"ciBranchUsed":                 "CIBranchUsed",
+		"mainBranchDivergenceLocalRef": "MainBranchDivergenceLocalRef",
+	}
+	for name, expected := range tests {
+		if got := goName(name); got != expected {
+			t.Errorf("goName(%q) = %q, want %q", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package emit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// Jenkins writes the version to a Java properties file, which pipelines load with
+// readProperties (Pipeline Utility Steps) and freestyle jobs with the EnvInject plugin
+type Jenkins struct {
+	Path   string
+	Prefix string // prefix of the variable names
+}
+
+// NewJenkins returns a Jenkins writer for the default properties file
+func NewJenkins(prefix string) *Jenkins {
+	return &Jenkins{Path: defaults.DefaultPropertiesFile, Prefix: prefix}
+}
+
+// Provider returns the CI provider the writer is for
+func (j *Jenkins) Provider() string {
+	return "jenkins"
+}
+
+// Write writes every variable to the properties file as NAME=value, replacing the file
+func (j *Jenkins) Write(output string, result *version.Result) error {
+	var b strings.Builder
+	for _, v := range variables(j.Prefix, output, result) {
+		fmt.Fprintf(&b, "%s=%s\n", v.Name, escapeProperty(v.Value))
+	}
+
+	if err := os.WriteFile(j.Path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write Jenkins properties file: %w", err)
+	}
+	return nil
+}
+
+// escapeProperty escapes a value for a Java properties file
+func escapeProperty(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "=", `\=`, ":", `\:`, "#", `\#`, "!", `\!`).Replace(value)
+	// Leading whitespace would be stripped
+	if strings.HasPrefix(escaped, " ") {
+		escaped = `\` + escaped
+	}
+	return escaped
+}
@@ -0,0 +1,40 @@
+package emit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// TeamCity publishes the version with TeamCity service messages
+type TeamCity struct {
+	Out    io.Writer
+	Prefix string // prefix of the variable names
+}
+
+// Provider returns the CI provider the writer is for
+func (tc *TeamCity) Provider() string {
+	return "teamcity"
+}
+
+// Write sets every variable as an env.* build parameter (so later steps see it as an environment
+// variable) and sets the build number to the version with the configured prefix
+func (tc *TeamCity) Write(output string, result *version.Result) error {
+	var b strings.Builder
+	for _, v := range variables(tc.Prefix, output, result) {
+		fmt.Fprintf(&b, "##teamcity[setParameter name='env.%s' value='%s']\n", v.Name, escapeTeamCity(v.Value))
+	}
+	fmt.Fprintf(&b, "##teamcity[buildNumber '%s']\n", escapeTeamCity(result.SemverWithPrefix))
+
+	if _, err := io.WriteString(tc.Out, b.String()); err != nil {
+		return fmt.Errorf("failed to write TeamCity service messages: %w", err)
+	}
+	return nil
+}
+
+// escapeTeamCity escapes a service message value
+func escapeTeamCity(value string) string {
+	return strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace(value)
+}
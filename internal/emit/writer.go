@@ -0,0 +1,110 @@
+package emit
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/ci"
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/version"
+)
+
+// Writer publishes a calculated version through the native mechanism of a CI system, such as
+// step outputs, build variables or the build number
+type Writer interface {
+	// Provider returns the CI provider the writer is for (a key of defaults.WellKnownCIProviders)
+	Provider() string
+	// Write publishes the formatted output and the fields of the result
+	Write(output string, result *version.Result) error
+}
+
+// Options configure the writers
+type Options struct {
+	Stdout io.Writer // where writers that use service messages print them, the CI agent reads them there
+	Prefix string    // prefix of the variable names (see VariablePrefix)
+}
+
+// writers creates the writer for each supported CI provider
+var writers = map[string]func(opts Options) Writer{
+	"github-actions":  func(opts Options) Writer { return NewGitHubActions(opts.Prefix) },
+	"gitlab-ci":       func(opts Options) Writer { return NewGitLab(opts.Prefix) },
+	"azure-pipelines": func(opts Options) Writer { return &AzurePipelines{Out: opts.Stdout, Prefix: opts.Prefix} },
+	"teamcity":        func(opts Options) Writer { return &TeamCity{Out: opts.Stdout, Prefix: opts.Prefix} },
+	"jenkins":         func(opts Options) Writer { return NewJenkins(opts.Prefix) },
+}
+
+// Providers returns the names of the CI providers that have a writer
+func Providers() []string {
+	names := make([]string, 0, len(writers))
+	for name := range writers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForProvider returns the writer for a CI provider
+// "ci" picks the writer for the CI provider autoversion is running in.
+func ForProvider(provider string, opts Options) (Writer, error) {
+	if provider == "ci" {
+		detected, ok := ci.DetectProvider()
+		if !ok {
+			return nil, fmt.Errorf("no CI provider detected. Use one of %v instead of 'ci' to choose one", Providers())
+		}
+		provider = detected
+	}
+
+	newWriter, ok := writers[provider]
+	if !ok {
+		return nil, fmt.Errorf("no output writer for CI provider '%s': must be 'ci' or one of %v", provider, Providers())
+	}
+	return newWriter(opts), nil
+}
+
+// validVariablePrefix matches prefixes that keep variable names valid in every shell and CI system
+var validVariablePrefix = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// VariablePrefix returns the configured prefix of the variable names the version is published as
+func VariablePrefix(cfg *config.Config) (string, error) {
+	prefix := defaults.DefaultVariablePrefix
+	if cfg.VariablePrefix != nil {
+		prefix = *cfg.VariablePrefix
+	}
+	if !validVariablePrefix.MatchString(prefix) {
+		return "", fmt.Errorf("invalid variablePrefix '%s': must start with a letter or underscore and contain only letters, digits and underscores", prefix)
+	}
+	return prefix, nil
+}
+
+// variable is a named value published to a CI system
+type variable struct {
+	Name  string
+	Value string
+}
+
+// variables returns the formatted output as <prefix>VERSION followed by the output fields
+func variables(prefix, output string, result *version.Result) []variable {
+	return append([]variable{{Name: prefix + "VERSION", Value: output}}, fieldVariables(prefix, result)...)
+}
+
+// fieldVariables returns every field of the JSON output as <prefix><FIELD>
+// (e.g. AUTOVERSION_SEMVER_WITH_PREFIX)
+func fieldVariables(prefix string, result *version.Result) []variable {
+	var vars []variable
+	for _, field := range version.OutputFields(result) {
+		vars = append(vars, variable{Name: envName(prefix, field.Name), Value: field.Value})
+	}
+	return vars
+}
+
+// singleLine returns an error if a value can't be written to a line based format
+func singleLine(name, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("the value of %s spans multiple lines, which this output format doesn't support", name)
+	}
+	return nil
+}
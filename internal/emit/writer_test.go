@@ -0,0 +1,142 @@
+package emit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearProviderEnv makes sure no CI provider is detected from the test environment
+func clearProviderEnv(t *testing.T) {
+	t.Helper()
+	for _, envVar := range []string{"GITHUB_ACTIONS", "GITLAB_CI", "CIRCLECI", "TRAVIS", "JENKINS_URL", "TF_BUILD", "TEAMCITY_VERSION"} {
+		t.Setenv(envVar, "")
+	}
+}
+
+func TestForProvider(t *testing.T) {
+	clearProviderEnv(t)
+
+	if _, err := ForProvider("ci", Options{Stdout: &bytes.Buffer{}}); err == nil {
+		t.Errorf("Expected error when no CI provider is detected")
+	}
+	if _, err := ForProvider("unknown", Options{Stdout: &bytes.Buffer{}}); err == nil {
+		t.Errorf("Expected error for unknown provider")
+	}
+
+	tests := map[string]string{
+		"GITLAB_CI":        "gitlab-ci",
+		"TF_BUILD":         "azure-pipelines",
+		"TEAMCITY_VERSION": "teamcity",
+		"JENKINS_URL":      "jenkins",
+		"GITHUB_ACTIONS":   "github-actions",
+	}
+	for envVar, provider := range tests {
+		t.Run(provider, func(t *testing.T) {
+			clearProviderEnv(t)
+			t.Setenv(envVar, "true")
+
+			writer, err := ForProvider("ci", Options{Stdout: &bytes.Buffer{}})
+			if err != nil {
+				t.Fatalf("ForProvider failed: %v", err)
+			}
+			if writer.Provider() != provider {
+				t.Errorf("Expected %s writer, got %s", provider, writer.Provider())
+			}
+		})
+	}
+
+	// A detected provider without a writer is an error
+	clearProviderEnv(t)
+	t.Setenv("CIRCLECI", "true")
+	if _, err := ForProvider("ci", Options{Stdout: &bytes.Buffer{}}); err == nil {
+		t.Errorf("Expected error for a provider without a writer")
+	}
+}
+
+func TestGitLabWrite(t *testing.T) {
+	gitlab := &GitLab{Path: filepath.Join(t.TempDir(), "autoversion.env"), Prefix: "AUTOVERSION_"}
+	if err := gitlab.Write("1.2.4-login.3", testResult()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	content, err := os.ReadFile(gitlab.Path)
+	if err != nil {
+		t.Fatalf("Failed to read dotenv file: %v", err)
+	}
+	for _, expected := range []string{
+		"AUTOVERSION_VERSION=1.2.4-login.3\n",
+		"AUTOVERSION_SEMVER_WITH_PREFIX=v1.2.4-login.3\n",
+		"AUTOVERSION_MAJOR=1\n",
+		"AUTOVERSION_IS_RELEASE=false\n",
+		"AUTOVERSION_BRANCH=feature/login\n",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Dotenv file is missing %q:\n%s", expected, content)
+		}
+	}
+
+	if err := gitlab.Write("line one\nline two", testResult()); err == nil {
+		t.Errorf("Expected error for a multiline value")
+	}
+}
+
+func TestAzurePipelinesWrite(t *testing.T) {
+	var out bytes.Buffer
+	azure := &AzurePipelines{Out: &out, Prefix: "AUTOVERSION_"}
+	if err := azure.Write("100%\nsure", testResult()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"##vso[task.setvariable variable=AUTOVERSION_VERSION]100%AZP25%0Asure\n",
+		"##vso[task.setvariable variable=AUTOVERSION_SEMVER]1.2.4-login.3\n",
+		"##vso[task.setvariable variable=AUTOVERSION_SEMVER;isoutput=true]1.2.4-login.3\n",
+		"##vso[build.updatebuildnumber]v1.2.4-login.3\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Output is missing %q:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestTeamCityWrite(t *testing.T) {
+	var out bytes.Buffer
+	teamcity := &TeamCity{Out: &out, Prefix: "AUTOVERSION_"}
+	if err := teamcity.Write("it's [1.2.4]|x", testResult()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"##teamcity[setParameter name='env.AUTOVERSION_VERSION' value='it|'s |[1.2.4|]||x']\n",
+		"##teamcity[setParameter name='env.AUTOVERSION_PEP440' value='1.2.4a3']\n",
+		"##teamcity[buildNumber 'v1.2.4-login.3']\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Output is missing %q:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestJenkinsWrite(t *testing.T) {
+	jenkins := &Jenkins{Path: filepath.Join(t.TempDir(), "autoversion.properties"), Prefix: "AUTOVERSION_"}
+	if err := jenkins.Write(`{"semver":"1.2.4"}`, testResult()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	content, err := os.ReadFile(jenkins.Path)
+	if err != nil {
+		t.Fatalf("Failed to read properties file: %v", err)
+	}
+	for _, expected := range []string{
+		`AUTOVERSION_VERSION={"semver"\:"1.2.4"}` + "\n",
+		"AUTOVERSION_SEMVER=1.2.4-login.3\n",
+		"AUTOVERSION_COMMITS_SINCE_TAG=3\n",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Properties file is missing %q:\n%s", expected, content)
+		}
+	}
+}
@@ -5,19 +5,25 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/trondhindenes/autoversion/internal/defaults"
 )
 
 // Repo represents a git repository
 type Repo struct {
-	repo *git.Repository
+	repo  *git.Repository
+	path  string // worktree root, where the git command is run
+	graph *commitGraph
+	// remotes are the remotes searched for remote-tracking branches, in order of preference
+	remotes []string
+	// preferRemoteMain resolves main branches from the remote before the local branch
+	preferRemoteMain bool
 }
 
 // OpenRepo opens a git repository at the given path
@@ -32,7 +38,27 @@ func OpenRepo(path string) (*Repo, error) {
 		return nil, fmt.Errorf("failed to open git repository: %w", err)
 	}
 
-	return &Repo{repo: repo}, nil
+	return &Repo{
+		repo:    repo,
+		path:    absPath,
+		graph:   newCommitGraph(),
+		remotes: []string{defaults.DefaultRemote},
+	}, nil
+}
+
+// SetRemotes sets the remotes used to look up remote-tracking branches, in order of preference
+// An empty list resets it to the default remote
+func (g *Repo) SetRemotes(remotes []string) {
+	if len(remotes) == 0 {
+		remotes = []string{defaults.DefaultRemote}
+	}
+	g.remotes = remotes
+}
+
+// SetPreferRemoteMainBranch controls whether main branches are resolved from the remote-tracking
+// branch before the local branch. This avoids versioning against a stale local main branch.
+func (g *Repo) SetPreferRemoteMainBranch(prefer bool) {
+	g.preferRemoteMain = prefer
 }
 
 // IsShallow checks if the repository is a shallow clone
@@ -108,70 +134,266 @@ func IsMainBranch(currentBranch string, mainBranches []string) bool {
 // It checks both local and remote branches to handle detached HEAD states in CI
 func (g *Repo) GetMainBranch(mainBranches []string) (string, error) {
 	for _, branchName := range mainBranches {
-		// Try local branch first
-		branchRefName := plumbing.NewBranchReferenceName(branchName)
-		_, err := g.repo.Reference(branchRefName, true)
-		if err == nil {
-			return branchName, nil
-		}
-
-		// Try remote branch (e.g., origin/main, origin/master)
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", branchName)
-		_, err = g.repo.Reference(remoteBranchRefName, true)
-		if err == nil {
+		if _, err := g.resolveMainBranchRef(branchName); err == nil {
 			return branchName, nil
 		}
 	}
 	return "", fmt.Errorf("none of the configured main branches exist: %v", mainBranches)
 }
 
-// GetCommitCount returns the number of commits on the current branch
-func (g *Repo) GetCommitCount() (int, error) {
+// HeadHash returns the commit hash that HEAD points to
+func (g *Repo) HeadHash() (plumbing.Hash, error) {
 	head, err := g.repo.Head()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get HEAD: %w", err)
+		return plumbing.ZeroHash, fmt.Errorf("failed to get HEAD: %w", err)
 	}
+	return head.Hash(), nil
+}
 
-	commitIter, err := g.repo.Log(&git.LogOptions{From: head.Hash()})
+// ResolveRef resolves a revision (commit SHA, abbreviated SHA, branch, remote branch or tag)
+// to the hash of the commit it points to. Annotated tags are peeled to their target commit.
+func (g *Repo) ResolveRef(rev string) (plumbing.Hash, error) {
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(rev))
 	if err != nil {
-		return 0, fmt.Errorf("failed to get commit log: %w", err)
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve '%s': %w", rev, err)
 	}
+	return *hash, nil
+}
 
-	count := 0
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		count++
+// ResolveBranch returns the commit hash of the given branch
+// It checks the local branch first, then the remote-tracking branch
+func (g *Repo) ResolveBranch(branch string) (plumbing.Hash, error) {
+	ref, err := g.resolveBranchRef(branch)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return ref.Hash(), nil
+}
+
+// ResolveMainBranch returns the commit hash of the given main branch
+// Unlike ResolveBranch, the remote-tracking branch is checked first when preferred
+func (g *Repo) ResolveMainBranch(branch string) (plumbing.Hash, error) {
+	ref, err := g.resolveMainBranchRef(branch)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return ref.Hash(), nil
+}
+
+// BranchForRef returns the branch name a revision refers to, if the revision is
+// the name of a local branch or a remote-tracking branch (e.g. "origin/feature")
+func (g *Repo) BranchForRef(rev string) (string, bool) {
+	if _, err := g.repo.Reference(plumbing.NewBranchReferenceName(rev), true); err == nil {
+		return rev, true
+	}
+	for _, remote := range g.remotes {
+		if !strings.HasPrefix(rev, remote+"/") {
+			continue
+		}
+		branch := strings.TrimPrefix(rev, remote+"/")
+		if _, err := g.repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true); err == nil {
+			return branch, true
+		}
+	}
+	return "", false
+}
+
+// Branch is a local or remote-tracking branch
+type Branch struct {
+	Name   string // branch name without the remote (e.g. "feature/login")
+	Remote string // remote name for remote-tracking branches, empty for local branches
+	Hash   plumbing.Hash
+}
+
+// RefName returns the name used to refer to the branch (e.g. "feature/login" or "origin/feature/login")
+func (b Branch) RefName() string {
+	if b.Remote == "" {
+		return b.Name
+	}
+	return b.Remote + "/" + b.Name
+}
+
+// ListBranches returns all local branches followed by the remote-tracking branches of each
+// configured remote (in order of preference), each group sorted by name
+func (g *Repo) ListBranches() ([]Branch, error) {
+	refs, err := g.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get references: %w", err)
+	}
+
+	var local []Branch
+	remoteBranches := make(map[string][]Branch)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		// Skip symbolic references such as origin/HEAD
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		name := ref.Name()
+		if name.IsBranch() {
+			local = append(local, Branch{Name: name.Short(), Hash: ref.Hash()})
+			return nil
+		}
+		if !name.IsRemote() {
+			return nil
+		}
+		for _, remote := range g.remotes {
+			remotePrefix := "refs/remotes/" + remote + "/"
+			if !strings.HasPrefix(name.String(), remotePrefix) {
+				continue
+			}
+			branchName := strings.TrimPrefix(name.String(), remotePrefix)
+			if branchName != "HEAD" {
+				remoteBranches[remote] = append(remoteBranches[remote], Branch{Name: branchName, Remote: remote, Hash: ref.Hash()})
+			}
+			break
+		}
 		return nil
 	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to iterate commits: %w", err)
+		return nil, fmt.Errorf("failed to iterate references: %w", err)
 	}
 
-	return count, nil
+	sort.Slice(local, func(i, j int) bool { return local[i].Name < local[j].Name })
+	branches := local
+	for _, remote := range g.remotes {
+		remoteList := remoteBranches[remote]
+		sort.Slice(remoteList, func(i, j int) bool { return remoteList[i].Name < remoteList[j].Name })
+		branches = append(branches, remoteList...)
+	}
+	return branches, nil
 }
 
-// GetMainBranchCommitCount returns the commit count on the main branch
-// It checks both local and remote branches to handle detached HEAD states in CI
-func (g *Repo) GetMainBranchCommitCount(mainBranch string) (int, error) {
-	// Try local branch first
-	refName := plumbing.NewBranchReferenceName(mainBranch)
-	ref, err := g.repo.Reference(refName, true)
+// IsAncestor returns true if ancestor is reachable from descendant (or is the same commit)
+func (g *Repo) IsAncestor(ancestor, descendant plumbing.Hash) (bool, error) {
+	found := false
+	err := g.walk(descendant, func(hash plumbing.Hash) error {
+		if hash == ancestor {
+			found = true
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to iterate commits: %w", err)
+	}
 
-	// If local branch doesn't exist, try remote branch
+	return found, nil
+}
+
+// resolveBranchRef returns the reference for a branch
+// It tries the local branch first, then the remote branches (e.g., origin/main) in order
+func (g *Repo) resolveBranchRef(branch string) (*plumbing.Reference, error) {
+	ref, err := g.repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err == nil {
+		return ref, nil
+	}
+
+	if ref, err := g.resolveRemoteBranchRef(branch); err == nil {
+		return ref, nil
+	}
+	return nil, fmt.Errorf("failed to get %s branch reference (tried both local and remote %v): %w", branch, g.remotes, err)
+}
+
+// resolveMainBranchRef returns the reference for a main branch
+// When the remote is preferred, the remote branches are tried before the local branch
+func (g *Repo) resolveMainBranchRef(branch string) (*plumbing.Reference, error) {
+	if !g.preferRemoteMain {
+		return g.resolveBranchRef(branch)
+	}
+
+	if ref, err := g.resolveRemoteBranchRef(branch); err == nil {
+		return ref, nil
+	}
+	ref, err := g.repo.Reference(plumbing.NewBranchReferenceName(branch), true)
 	if err != nil {
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", mainBranch)
-		ref, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			return 0, fmt.Errorf("failed to get %s branch reference (tried both local and remote): %w", mainBranch, err)
+		return nil, fmt.Errorf("failed to get %s branch reference (tried both remote %v and local): %w", branch, g.remotes, err)
+	}
+	return ref, nil
+}
+
+// resolveRemoteBranchRef returns the remote-tracking reference for a branch from the first
+// configured remote that has it
+func (g *Repo) resolveRemoteBranchRef(branch string) (*plumbing.Reference, error) {
+	err := plumbing.ErrReferenceNotFound
+	for _, remote := range g.remotes {
+		var ref *plumbing.Reference
+		ref, err = g.repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true)
+		if err == nil {
+			return ref, nil
 		}
 	}
+	return nil, err
+}
+
+// MainBranchDivergence describes how a local main branch differs from its remote-tracking branch
+type MainBranchDivergence struct {
+	LocalRef  string // local branch name (e.g. "main")
+	RemoteRef string // remote-tracking branch name (e.g. "origin/main")
+	UsedRef   string // the one that version calculations use, based on the main branch source
+	Ahead     int    // commits on the local branch that are not on the remote-tracking branch
+	Behind    int    // commits on the remote-tracking branch that are not on the local branch
+}
+
+// Diverged returns true if the local and remote-tracking branch point to different commits
+func (d MainBranchDivergence) Diverged() bool {
+	return d.Ahead > 0 || d.Behind > 0
+}
+
+// GetMainBranchDivergence compares the local main branch with its remote-tracking branch
+// It returns nil when either of them doesn't exist, since there is nothing to compare
+func (g *Repo) GetMainBranchDivergence(mainBranch string) (*MainBranchDivergence, error) {
+	localRef, err := g.repo.Reference(plumbing.NewBranchReferenceName(mainBranch), true)
+	if err != nil {
+		return nil, nil
+	}
+	remoteRef, err := g.resolveRemoteBranchRef(mainBranch)
+	if err != nil {
+		return nil, nil
+	}
+
+	divergence := &MainBranchDivergence{
+		LocalRef:  localRef.Name().Short(),
+		RemoteRef: remoteRef.Name().Short(),
+		UsedRef:   localRef.Name().Short(),
+	}
+	if g.preferRemoteMain {
+		divergence.UsedRef = remoteRef.Name().Short()
+	}
+	if localRef.Hash() == remoteRef.Hash() {
+		return divergence, nil
+	}
 
-	commitIter, err := g.repo.Log(&git.LogOptions{From: ref.Hash()})
+	localAncestors, err := g.Ancestors(localRef.Hash())
+	if err != nil {
+		return nil, err
+	}
+	remoteAncestors, err := g.Ancestors(remoteRef.Hash())
 	if err != nil {
-		return 0, fmt.Errorf("failed to get commit log: %w", err)
+		return nil, err
+	}
+	for hash := range localAncestors {
+		if !remoteAncestors[hash] {
+			divergence.Ahead++
+		}
+	}
+	for hash := range remoteAncestors {
+		if !localAncestors[hash] {
+			divergence.Behind++
+		}
+	}
+
+	return divergence, nil
+}
+
+// GetCommitCount returns the number of commits reachable from the given commit
+func (g *Repo) GetCommitCount(from plumbing.Hash) (int, error) {
+	if count, ok := g.graph.commitCounts[from]; ok {
+		return count, nil
 	}
 
 	count := 0
-	err = commitIter.ForEach(func(c *object.Commit) error {
+	err := g.walk(from, func(hash plumbing.Hash) error {
 		count++
 		return nil
 	})
@@ -179,71 +401,47 @@ func (g *Repo) GetMainBranchCommitCount(mainBranch string) (int, error) {
 		return 0, fmt.Errorf("failed to iterate commits: %w", err)
 	}
 
+	g.graph.commitCounts[from] = count
 	return count, nil
 }
 
-// GetCommitCountSinceBranchPoint returns the number of commits since branching from main
-// This uses a proper merge-base algorithm to find the common ancestor
-func (g *Repo) GetCommitCountSinceBranchPoint(mainBranch, currentBranch string) (int, error) {
-	if currentBranch == mainBranch {
-		return 0, nil
-	}
-
-	// Get reference for the current branch
-	// Try local branch first, then remote (important for CI environments)
-	currentBranchRefName := plumbing.NewBranchReferenceName(currentBranch)
-	currentRef, err := g.repo.Reference(currentBranchRefName, true)
-
+// GetMainBranchCommitCount returns the commit count on the main branch
+// It checks both local and remote branches to handle detached HEAD states in CI
+func (g *Repo) GetMainBranchCommitCount(mainBranch string) (int, error) {
+	ref, err := g.resolveMainBranchRef(mainBranch)
 	if err != nil {
-		// Local branch doesn't exist, try remote branch (e.g., origin/feature-branch)
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", currentBranch)
-		currentRef, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			// If we can't find the branch reference, fall back to HEAD
-			// This handles cases where we're in detached HEAD state
-			head, err := g.repo.Head()
-			if err != nil {
-				return 0, fmt.Errorf("failed to get HEAD and couldn't find branch reference: %w", err)
-			}
-			currentRef = head
-		}
+		return 0, err
 	}
 
-	// Get reference for the main branch
-	// Try local branch first, then remote
-	mainRefName := plumbing.NewBranchReferenceName(mainBranch)
-	mainRef, err := g.repo.Reference(mainRefName, true)
+	return g.GetCommitCount(ref.Hash())
+}
 
+// GetCommitCountSinceBranchPoint returns the number of commits since branching from main
+// The current commit is the tip of the branch being versioned
+// This uses a proper merge-base algorithm to find the common ancestor
+func (g *Repo) GetCommitCountSinceBranchPoint(mainBranch string, current plumbing.Hash) (int, error) {
+	mainRef, err := g.resolveMainBranchRef(mainBranch)
 	if err != nil {
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", mainBranch)
-		mainRef, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			return 0, fmt.Errorf("failed to get %s branch reference (tried both local and remote): %w", mainBranch, err)
-		}
+		return 0, err
 	}
 
 	// Find merge base (common ancestor) between current branch and main branch
 	// This properly handles cases where main has moved forward after the branch was created
-	mergeBase, err := g.findMergeBase(currentRef.Hash(), mainRef.Hash())
+	mergeBase, err := g.findMergeBase(current, mainRef.Hash())
 	if err != nil {
 		return 0, fmt.Errorf("failed to find merge base: %w", err)
 	}
 
 	// Count commits from current branch back to merge base
 	count := 0
-	commitIter, err := g.repo.Log(&git.LogOptions{From: currentRef.Hash()})
-	if err != nil {
-		return 0, fmt.Errorf("failed to get commit log: %w", err)
-	}
-
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		if c.Hash == mergeBase {
+	err = g.walk(current, func(hash plumbing.Hash) error {
+		if hash == mergeBase {
 			return storer.ErrStop
 		}
 		count++
 		return nil
 	})
-	if err != nil && err != storer.ErrStop {
+	if err != nil {
 		return 0, fmt.Errorf("failed to count commits since branch point: %w", err)
 	}
 
@@ -254,39 +452,22 @@ func (g *Repo) GetCommitCountSinceBranchPoint(mainBranch, currentBranch string)
 // This implements a simplified version of git merge-base
 func (g *Repo) findMergeBase(commit1Hash, commit2Hash plumbing.Hash) (plumbing.Hash, error) {
 	// Get all ancestors of commit1
-	ancestors1 := make(map[plumbing.Hash]int)
-	distance := 0
-
-	commitIter, err := g.repo.Log(&git.LogOptions{From: commit1Hash})
-	if err != nil {
-		return plumbing.ZeroHash, err
-	}
-
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		ancestors1[c.Hash] = distance
-		distance++
-		return nil
-	})
+	ancestors1, err := g.Ancestors(commit1Hash)
 	if err != nil {
 		return plumbing.ZeroHash, err
 	}
 
 	// Walk commit2's history until we find a commit that's also in commit1's history
 	// This is the merge base
-	commitIter2, err := g.repo.Log(&git.LogOptions{From: commit2Hash})
-	if err != nil {
-		return plumbing.ZeroHash, err
-	}
-
 	var mergeBase plumbing.Hash
-	err = commitIter2.ForEach(func(c *object.Commit) error {
-		if _, exists := ancestors1[c.Hash]; exists {
-			mergeBase = c.Hash
+	err = g.walk(commit2Hash, func(hash plumbing.Hash) error {
+		if ancestors1[hash] {
+			mergeBase = hash
 			return storer.ErrStop
 		}
 		return nil
 	})
-	if err != nil && err != storer.ErrStop {
+	if err != nil {
 		return plumbing.ZeroHash, err
 	}
 
@@ -298,63 +479,29 @@ func (g *Repo) findMergeBase(commit1Hash, commit2Hash plumbing.Hash) (plumbing.H
 }
 
 // GetMainBranchCommitsSinceBranchPoint returns the number of commits on main branch
-// since the point where the current branch diverged from main
-func (g *Repo) GetMainBranchCommitsSinceBranchPoint(mainBranch, currentBranch string) (int, error) {
-	if currentBranch == mainBranch {
-		return 0, nil
-	}
-
-	// Get reference for the current branch
-	currentBranchRefName := plumbing.NewBranchReferenceName(currentBranch)
-	currentRef, err := g.repo.Reference(currentBranchRefName, true)
-
+// since the point where the current commit diverged from main
+func (g *Repo) GetMainBranchCommitsSinceBranchPoint(mainBranch string, current plumbing.Hash) (int, error) {
+	mainRef, err := g.resolveMainBranchRef(mainBranch)
 	if err != nil {
-		// Local branch doesn't exist, try remote branch
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", currentBranch)
-		currentRef, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			// If we can't find the branch reference, fall back to HEAD
-			head, err := g.repo.Head()
-			if err != nil {
-				return 0, fmt.Errorf("failed to get HEAD and couldn't find branch reference: %w", err)
-			}
-			currentRef = head
-		}
-	}
-
-	// Get reference for the main branch
-	mainRefName := plumbing.NewBranchReferenceName(mainBranch)
-	mainRef, err := g.repo.Reference(mainRefName, true)
-
-	if err != nil {
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", mainBranch)
-		mainRef, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			return 0, fmt.Errorf("failed to get %s branch reference (tried both local and remote): %w", mainBranch, err)
-		}
+		return 0, err
 	}
 
 	// Find merge base (common ancestor)
-	mergeBase, err := g.findMergeBase(currentRef.Hash(), mainRef.Hash())
+	mergeBase, err := g.findMergeBase(current, mainRef.Hash())
 	if err != nil {
 		return 0, fmt.Errorf("failed to find merge base: %w", err)
 	}
 
 	// Count commits from main branch HEAD back to merge base
 	count := 0
-	commitIter, err := g.repo.Log(&git.LogOptions{From: mainRef.Hash()})
-	if err != nil {
-		return 0, fmt.Errorf("failed to get commit log: %w", err)
-	}
-
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		if c.Hash == mergeBase {
+	err = g.walk(mainRef.Hash(), func(hash plumbing.Hash) error {
+		if hash == mergeBase {
 			return storer.ErrStop
 		}
 		count++
 		return nil
 	})
-	if err != nil && err != storer.ErrStop {
+	if err != nil {
 		return 0, fmt.Errorf("failed to count commits on main since branch point: %w", err)
 	}
 
@@ -362,89 +509,42 @@ func (g *Repo) GetMainBranchCommitsSinceBranchPoint(mainBranch, currentBranch st
 }
 
 // CheckMainBranchHasNewTagsSinceBranchPoint checks if main branch has been tagged
-// after the current branch diverged from it. Returns true if main has new tags,
+// after the current commit diverged from it. Returns true if main has new tags,
 // along with the most recent tag name on main if found.
-func (g *Repo) CheckMainBranchHasNewTagsSinceBranchPoint(mainBranch, currentBranch string) (bool, string, error) {
-	if currentBranch == mainBranch {
-		return false, "", nil
-	}
-
-	// Get reference for the current branch
-	currentBranchRefName := plumbing.NewBranchReferenceName(currentBranch)
-	currentRef, err := g.repo.Reference(currentBranchRefName, true)
-
+func (g *Repo) CheckMainBranchHasNewTagsSinceBranchPoint(mainBranch string, current plumbing.Hash) (bool, string, error) {
+	mainRef, err := g.resolveMainBranchRef(mainBranch)
 	if err != nil {
-		// Local branch doesn't exist, try remote branch
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", currentBranch)
-		currentRef, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			// If we can't find the branch reference, fall back to HEAD
-			head, err := g.repo.Head()
-			if err != nil {
-				return false, "", fmt.Errorf("failed to get HEAD and couldn't find branch reference: %w", err)
-			}
-			currentRef = head
-		}
-	}
-
-	// Get reference for the main branch
-	mainRefName := plumbing.NewBranchReferenceName(mainBranch)
-	mainRef, err := g.repo.Reference(mainRefName, true)
-
-	if err != nil {
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", mainBranch)
-		mainRef, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			return false, "", fmt.Errorf("failed to get %s branch reference (tried both local and remote): %w", mainBranch, err)
-		}
+		return false, "", err
 	}
 
 	// Find merge base (common ancestor)
-	mergeBase, err := g.findMergeBase(currentRef.Hash(), mainRef.Hash())
+	mergeBase, err := g.findMergeBase(current, mainRef.Hash())
 	if err != nil {
 		return false, "", fmt.Errorf("failed to find merge base: %w", err)
 	}
 
-	// Get all tags
-	tagRefs, err := g.repo.Tags()
+	// Build a map of commit hash to tag name
+	tags, err := g.taggedCommits()
 	if err != nil {
-		return false, "", fmt.Errorf("failed to get tags: %w", err)
+		return false, "", err
 	}
-
-	// Build a map of commit hash to tag name
 	tagMap := make(map[plumbing.Hash]string)
-	err = tagRefs.ForEach(func(ref *plumbing.Reference) error {
-		tagMap[ref.Hash()] = ref.Name().Short()
-
-		// Also check annotated tags
-		tag, err := g.repo.TagObject(ref.Hash())
-		if err == nil {
-			tagMap[tag.Target] = ref.Name().Short()
-		}
-
-		return nil
-	})
-	if err != nil {
-		return false, "", fmt.Errorf("failed to iterate tags: %w", err)
+	for _, tag := range tags {
+		tagMap[tag.commit] = tag.name
 	}
 
 	// Walk the main branch history from its HEAD to the merge base
 	// and check if there are any tags in between
-	commitIter, err := g.repo.Log(&git.LogOptions{From: mainRef.Hash()})
-	if err != nil {
-		return false, "", fmt.Errorf("failed to get commit log: %w", err)
-	}
-
 	var foundTag string
 	foundNewTag := false
-	err = commitIter.ForEach(func(c *object.Commit) error {
+	err = g.walk(mainRef.Hash(), func(hash plumbing.Hash) error {
 		// Stop when we reach the merge base
-		if c.Hash == mergeBase {
+		if hash == mergeBase {
 			return storer.ErrStop
 		}
 
 		// Check if this commit has a tag
-		if tagName, exists := tagMap[c.Hash]; exists {
+		if tagName, exists := tagMap[hash]; exists {
 			if foundTag == "" {
 				foundTag = tagName // Remember the most recent tag
 			}
@@ -453,7 +553,7 @@ func (g *Repo) CheckMainBranchHasNewTagsSinceBranchPoint(mainBranch, currentBran
 
 		return nil
 	})
-	if err != nil && err != storer.ErrStop {
+	if err != nil {
 		return false, "", fmt.Errorf("failed to iterate commits on main since branch point: %w", err)
 	}
 
@@ -461,45 +561,16 @@ func (g *Repo) CheckMainBranchHasNewTagsSinceBranchPoint(mainBranch, currentBran
 }
 
 // CheckMainBranchHasNewCommitsSinceBranchPoint checks if main branch has any new commits
-// after the current branch diverged from it. Returns true if main has moved forward since the
+// after the current commit diverged from it. Returns true if main has moved forward since the
 // branch point. This is useful for detecting if a feature branch is outdated regardless of tags.
-func (g *Repo) CheckMainBranchHasNewCommitsSinceBranchPoint(mainBranch, currentBranch string) (bool, error) {
-	if currentBranch == mainBranch {
-		return false, nil
-	}
-
-	// Get reference for the current branch
-	currentBranchRefName := plumbing.NewBranchReferenceName(currentBranch)
-	currentRef, err := g.repo.Reference(currentBranchRefName, true)
-
-	if err != nil {
-		// Local branch doesn't exist, try remote branch
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", currentBranch)
-		currentRef, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			// If we can't find the branch reference, fall back to HEAD
-			head, err := g.repo.Head()
-			if err != nil {
-				return false, fmt.Errorf("failed to get HEAD and couldn't find branch reference: %w", err)
-			}
-			currentRef = head
-		}
-	}
-
-	// Get reference for the main branch
-	mainRefName := plumbing.NewBranchReferenceName(mainBranch)
-	mainRef, err := g.repo.Reference(mainRefName, true)
-
+func (g *Repo) CheckMainBranchHasNewCommitsSinceBranchPoint(mainBranch string, current plumbing.Hash) (bool, error) {
+	mainRef, err := g.resolveMainBranchRef(mainBranch)
 	if err != nil {
-		remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", mainBranch)
-		mainRef, err = g.repo.Reference(remoteBranchRefName, true)
-		if err != nil {
-			return false, fmt.Errorf("failed to get %s branch reference (tried both local and remote): %w", mainBranch, err)
-		}
+		return false, err
 	}
 
 	// Find merge base (common ancestor)
-	mergeBase, err := g.findMergeBase(currentRef.Hash(), mainRef.Hash())
+	mergeBase, err := g.findMergeBase(current, mainRef.Hash())
 	if err != nil {
 		return false, fmt.Errorf("failed to find merge base: %w", err)
 	}
@@ -513,42 +584,26 @@ func (g *Repo) CheckMainBranchHasNewCommitsSinceBranchPoint(mainBranch, currentB
 	return true, nil
 }
 
-// GetTagOnCurrentCommit returns the tag on the current HEAD commit, if any
-// When multiple tags point to the same commit, it returns the one with the highest semantic version
-func (g *Repo) GetTagOnCurrentCommit() (string, error) {
-	head, err := g.repo.Head()
-	if err != nil {
-		return "", fmt.Errorf("failed to get HEAD: %w", err)
-	}
-
-	headHash := head.Hash()
-
-	// Iterate through all tags
-	tagRefs, err := g.repo.Tags()
+// GetTagOnCommit returns the tag on the given commit, if any
+// If tagPrefix is provided, only tags with that prefix are considered. When multiple tags point to
+// the same commit, it returns the one with the highest semantic version after stripping the prefix.
+func (g *Repo) GetTagOnCommit(commitHash plumbing.Hash, tagPrefix string) (string, error) {
+	tags, err := g.taggedCommits()
 	if err != nil {
-		return "", fmt.Errorf("failed to get tags: %w", err)
+		return "", err
 	}
 
 	var foundTags []string
-	err = tagRefs.ForEach(func(ref *plumbing.Reference) error {
-		// Check if this tag points to the current commit
-		if ref.Hash() == headHash {
-			foundTags = append(foundTags, ref.Name().Short())
+	for _, tag := range tags {
+		// Check if this tag points to the given commit
+		if tag.commit != commitHash {
+			continue
 		}
-
-		// Check if it's an annotated tag
-		tag, err := g.repo.TagObject(ref.Hash())
-		if err == nil {
-			if tag.Target == headHash {
-				foundTags = append(foundTags, ref.Name().Short())
-			}
+		// Filter by prefix if specified
+		if tagPrefix != "" && !strings.HasPrefix(tag.name, tagPrefix) {
+			continue
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("failed to iterate tags: %w", err)
+		foundTags = append(foundTags, tag.name)
 	}
 
 	if len(foundTags) == 0 {
@@ -557,23 +612,24 @@ func (g *Repo) GetTagOnCurrentCommit() (string, error) {
 
 	// If multiple tags point to the same commit, select the one with the highest semantic version
 	if len(foundTags) > 1 {
-		return selectHighestSemverTag(foundTags), nil
+		return selectHighestSemverTag(foundTags, tagPrefix), nil
 	}
 
 	return foundTags[0], nil
 }
 
-// selectHighestSemverTag selects the tag with the highest semantic version from a list of tags
-func selectHighestSemverTag(tags []string) string {
+// selectHighestSemverTag selects the tag with the highest semantic version from a list of tags,
+// comparing the versions after the tag prefix
+func selectHighestSemverTag(tags []string, tagPrefix string) string {
 	if len(tags) == 0 {
 		return ""
 	}
 
 	highestTag := tags[0]
-	highestVersion, hasValidVersion := parseSemverSimple(highestTag)
+	highestVersion, hasValidVersion := parseSemverSimple(StripTagPrefix(highestTag, tagPrefix))
 
 	for i := 1; i < len(tags); i++ {
-		version, ok := parseSemverSimple(tags[i])
+		version, ok := parseSemverSimple(StripTagPrefix(tags[i], tagPrefix))
 		if !ok {
 			// Skip tags that can't be parsed as semver
 			continue
@@ -589,14 +645,9 @@ func selectHighestSemverTag(tags []string) string {
 	return highestTag
 }
 
-// IsTagInHistory checks if a tag is reachable from HEAD (i.e., merged into current branch)
-// Returns true if the tag is in the current branch's ancestry
-func (g *Repo) IsTagInHistory(tagName string) (bool, error) {
-	head, err := g.repo.Head()
-	if err != nil {
-		return false, fmt.Errorf("failed to get HEAD: %w", err)
-	}
-
+// IsTagInHistory checks if a tag is reachable from the given commit (i.e., merged into its branch)
+// Returns true if the tag is in the commit's ancestry
+func (g *Repo) IsTagInHistory(from plumbing.Hash, tagName string) (bool, error) {
 	// Find the tag reference
 	tagRef, err := g.repo.Tag(tagName)
 	if err != nil {
@@ -619,25 +670,8 @@ func (g *Repo) IsTagInHistory(tagName string) (bool, error) {
 		tagCommitHash = tag.Target
 	}
 
-	// Walk from HEAD back through history
-	commitIter, err := g.repo.Log(&git.LogOptions{From: head.Hash()})
-	if err != nil {
-		return false, fmt.Errorf("failed to get commit log: %w", err)
-	}
-
-	found := false
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		if c.Hash == tagCommitHash {
-			found = true
-			return storer.ErrStop
-		}
-		return nil
-	})
-	if err != nil && err != storer.ErrStop {
-		return false, fmt.Errorf("failed to iterate commits: %w", err)
-	}
-
-	return found, nil
+	// Walk from the given commit back through history
+	return g.IsAncestor(tagCommitHash, from)
 }
 
 // semverVersion is a simplified version struct for comparing semantic versions
@@ -698,27 +732,22 @@ func (v semverVersion) isGreaterThan(other semverVersion) bool {
 	return v.Patch > other.Patch
 }
 
-// GetMostRecentTag returns the most recent tag that is reachable from HEAD
-// Only tags that are in the current branch's history (merged) are considered
+// GetMostRecentTag returns the most recent tag that is reachable from the given commit
+// Only tags that are in the commit's history (merged) are considered
 // If tagPrefix is provided, only tags with that prefix are considered
 // Returns the tag name and commits since that tag (0 if we're on the tag)
 // The "most recent" tag is determined by highest semantic version, not by commit date
-func (g *Repo) GetMostRecentTag(tagPrefix string) (string, int, error) {
-	head, err := g.repo.Head()
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to get HEAD: %w", err)
+func (g *Repo) GetMostRecentTag(from plumbing.Hash, tagPrefix string) (string, int, error) {
+	key := mostRecentTagKey{commit: from, tagPrefix: tagPrefix}
+	if cached, ok := g.graph.mostRecentTags[key]; ok {
+		return cached.name, cached.distance, nil
 	}
 
-	// Build a map of all commits reachable from HEAD with their distance
+	// Build a map of all commits reachable from the given commit with their distance
 	reachableCommits := make(map[plumbing.Hash]int)
-	commitIter, err := g.repo.Log(&git.LogOptions{From: head.Hash()})
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to get commit log: %w", err)
-	}
-
 	commitDistance := 0
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		reachableCommits[c.Hash] = commitDistance
+	err := g.walk(from, func(hash plumbing.Hash) error {
+		reachableCommits[hash] = commitDistance
 		commitDistance++
 		return nil
 	})
@@ -726,81 +755,42 @@ func (g *Repo) GetMostRecentTag(tagPrefix string) (string, int, error) {
 		return "", 0, fmt.Errorf("failed to iterate commits: %w", err)
 	}
 
-	// Get all tags and filter to only those reachable from HEAD
-	tagRefs, err := g.repo.Tags()
+	// Get all tags and pick the most recent of those reachable from the given commit
+	tags, err := g.taggedCommits()
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to get tags: %w", err)
+		return "", 0, err
 	}
-
-	type tagInfo struct {
-		name     string
-		hash     plumbing.Hash
-		commit   *object.Commit
-		distance int
+	tag, ok := selectMostRecentTag(tags, tagPrefix, func(commit plumbing.Hash) bool {
+		_, reachable := reachableCommits[commit]
+		return reachable
+	})
+	if !ok {
+		g.graph.mostRecentTags[key] = mostRecentTag{}
+		return "", 0, nil
 	}
 
-	var reachableTags []tagInfo
+	g.graph.mostRecentTags[key] = mostRecentTag{name: tag.name, distance: reachableCommits[tag.commit]}
+	return tag.name, reachableCommits[tag.commit], nil
+}
 
-	err = tagRefs.ForEach(func(ref *plumbing.Reference) error {
-		tagName := ref.Name().Short()
+// selectMostRecentTag returns the tag with the highest semantic version among the reachable tags
+// with the prefix. When none of them is semver, the first one is returned.
+func selectMostRecentTag(tags []taggedCommit, tagPrefix string, reachable func(commit plumbing.Hash) bool) (taggedCommit, bool) {
+	var mostRecentTag *taggedCommit
+	var highestVersion semverVersion
+	hasValidVersion := false
 
+	for i := range tags {
 		// Filter by prefix if specified
-		if tagPrefix != "" {
-			if !strings.HasPrefix(tagName, tagPrefix) {
-				// Skip tags that don't match the prefix
-				return nil
-			}
-		}
-
-		// Handle lightweight tags
-		commit, err := g.repo.CommitObject(ref.Hash())
-		if err == nil {
-			// Check if this commit is reachable from HEAD
-			if distance, reachable := reachableCommits[ref.Hash()]; reachable {
-				reachableTags = append(reachableTags, tagInfo{
-					name:     tagName,
-					hash:     ref.Hash(),
-					commit:   commit,
-					distance: distance,
-				})
-			}
+		if tagPrefix != "" && !strings.HasPrefix(tags[i].name, tagPrefix) {
+			continue
 		}
-
-		// Also check annotated tags
-		tag, err := g.repo.TagObject(ref.Hash())
-		if err == nil {
-			commit, err := g.repo.CommitObject(tag.Target)
-			if err == nil {
-				// Check if the target commit is reachable from HEAD
-				if distance, reachable := reachableCommits[tag.Target]; reachable {
-					reachableTags = append(reachableTags, tagInfo{
-						name:     tagName,
-						hash:     tag.Target,
-						commit:   commit,
-						distance: distance,
-					})
-				}
-			}
+		if !reachable(tags[i].commit) {
+			continue
 		}
 
-		return nil
-	})
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to iterate tags: %w", err)
-	}
-
-	if len(reachableTags) == 0 {
-		return "", 0, nil
-	}
-
-	// Find the tag with the highest semantic version among reachable tags
-	var mostRecentTag *tagInfo
-	var highestVersion semverVersion
-	hasValidVersion := false
-
-	for i := range reachableTags {
 		// Strip prefix for version comparison
-		versionStr := StripTagPrefix(reachableTags[i].name, tagPrefix)
+		versionStr := StripTagPrefix(tags[i].name, tagPrefix)
 
 		// Try to parse as semver
 		version, ok := parseSemverSimple(versionStr)
@@ -808,7 +798,7 @@ func (g *Repo) GetMostRecentTag(tagPrefix string) (string, int, error) {
 			// If we can't parse as semver, skip this tag for version comparison
 			// but keep it as a fallback if no valid semver tags exist
 			if mostRecentTag == nil {
-				mostRecentTag = &reachableTags[i]
+				mostRecentTag = &tags[i]
 			}
 			continue
 		}
@@ -816,16 +806,15 @@ func (g *Repo) GetMostRecentTag(tagPrefix string) (string, int, error) {
 		// Compare versions
 		if !hasValidVersion || version.isGreaterThan(highestVersion) {
 			highestVersion = version
-			mostRecentTag = &reachableTags[i]
+			mostRecentTag = &tags[i]
 			hasValidVersion = true
 		}
 	}
 
 	if mostRecentTag == nil {
-		return "", 0, nil
+		return taggedCommit{}, false
 	}
-
-	return mostRecentTag.name, mostRecentTag.distance, nil
+	return *mostRecentTag, true
 }
 
 // StripTagPrefix removes the configured prefix from a tag name
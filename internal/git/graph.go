@@ -0,0 +1,357 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// commitGraph caches the parts of the repository that version calculations walk over and over:
+// the parent links of every commit that has been visited, the tags pointing at each commit and
+// the number of commits reachable from a commit. Commits are immutable, so the parent links
+// never go stale. This lets batch operations (like calculating the version of every commit on
+// a branch) share a single walk of the object database instead of re-reading it per commit.
+type commitGraph struct {
+	parents        map[plumbing.Hash][]plumbing.Hash
+	commitCounts   map[plumbing.Hash]int
+	mostRecentTags map[mostRecentTagKey]mostRecentTag
+	tags           []taggedCommit
+	tagsLoaded     bool
+}
+
+// mostRecentTagKey identifies a GetMostRecentTag lookup
+type mostRecentTagKey struct {
+	commit    plumbing.Hash
+	tagPrefix string
+}
+
+// mostRecentTag is the result of a GetMostRecentTag lookup
+type mostRecentTag struct {
+	name     string
+	distance int
+}
+
+// taggedCommit is a tag name and the commit it points to (annotated tags are peeled)
+type taggedCommit struct {
+	name   string
+	commit plumbing.Hash
+}
+
+func newCommitGraph() *commitGraph {
+	return &commitGraph{
+		parents:        make(map[plumbing.Hash][]plumbing.Hash),
+		commitCounts:   make(map[plumbing.Hash]int),
+		mostRecentTags: make(map[mostRecentTagKey]mostRecentTag),
+	}
+}
+
+// commitParents returns the parent hashes of a commit, reading each commit object only once
+func (g *Repo) commitParents(hash plumbing.Hash) ([]plumbing.Hash, error) {
+	if parents, ok := g.graph.parents[hash]; ok {
+		return parents, nil
+	}
+
+	commit, err := g.repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+	g.graph.parents[hash] = commit.ParentHashes
+	return commit.ParentHashes, nil
+}
+
+// walk visits every commit reachable from the given commit, in the same depth-first pre-order
+// that git log uses (the first-parent chain before the history of merged branches)
+// Returning storer.ErrStop from fn stops the walk without an error
+func (g *Repo) walk(from plumbing.Hash, fn func(hash plumbing.Hash) error) error {
+	return g.walkUnseen(from, make(map[plumbing.Hash]bool), fn)
+}
+
+// walkUnseen is walk over the commits that aren't in seen yet, adding them to it
+// The commits are visited in the order a walk from a descendant would visit them after the
+// commits in seen, so consecutive walks with the same seen set continue a single walk.
+func (g *Repo) walkUnseen(from plumbing.Hash, seen map[plumbing.Hash]bool, fn func(hash plumbing.Hash) error) error {
+	stack := [][]plumbing.Hash{{from}}
+
+	for len(stack) > 0 {
+		top := len(stack) - 1
+		if len(stack[top]) == 0 {
+			stack = stack[:top]
+			continue
+		}
+
+		hash := stack[top][0]
+		stack[top] = stack[top][1:]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		parents, err := g.commitParents(hash)
+		if err != nil {
+			return err
+		}
+
+		// Queue the parents that haven't been visited yet, first parent first
+		var unseen []plumbing.Hash
+		for _, parent := range parents {
+			if !seen[parent] {
+				unseen = append(unseen, parent)
+			}
+		}
+		if len(unseen) > 0 {
+			stack = append(stack, unseen)
+		}
+
+		if err := fn(hash); err != nil {
+			if err == storer.ErrStop {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FirstParents returns the first-parent history of a commit, newest first
+// The walk stops before the first commit for which stop returns true (stop may be nil)
+func (g *Repo) FirstParents(from plumbing.Hash, stop func(hash plumbing.Hash) bool) ([]plumbing.Hash, error) {
+	var hashes []plumbing.Hash
+	current := from
+	for {
+		if stop != nil && stop(current) {
+			break
+		}
+		hashes = append(hashes, current)
+
+		parents, err := g.commitParents(current)
+		if err != nil {
+			return nil, err
+		}
+		if len(parents) == 0 {
+			break
+		}
+		current = parents[0]
+	}
+	return hashes, nil
+}
+
+// IndexHistory finds the most recent tag and the commit count of every commit of a first-parent
+// history (newest first, as returned by FirstParents) in a single walk, so GetMostRecentTag and
+// GetCommitCount return them from the cache. The ancestors of a commit are the ancestors of its
+// first parent plus the commits its other parents bring in, so each step only walks the commits
+// that are new to it. The results are the same as those of separate lookups.
+func (g *Repo) IndexHistory(history []plumbing.Hash, tagPrefix string) error {
+	tags, err := g.taggedCommits()
+	if err != nil {
+		return err
+	}
+	tagged := make(map[plumbing.Hash]bool)
+	for _, tag := range tags {
+		tagged[tag.commit] = true
+	}
+
+	// A commit first reached at step i at distance d is at distance offset-j from the commit of
+	// every later step j, because each step adds one commit in front of the previous one
+	seen := make(map[plumbing.Hash]bool)
+	offsets := make(map[plumbing.Hash]int)
+	count := 0
+	var latest *taggedCommit
+	for i := len(history) - 1; i >= 0; i-- {
+		hash := history[i]
+		if i < len(history)-1 {
+			parents, err := g.commitParents(hash)
+			if err != nil {
+				return err
+			}
+			if len(parents) == 0 || parents[0] != history[i+1] {
+				return fmt.Errorf("%s is not the first parent of %s", history[i+1], hash)
+			}
+		}
+
+		// The commit itself comes first, then the ancestors of its first parent that are already
+		// known and then the commits merged in by its other parents
+		newTags := false
+		err := g.walkUnseen(hash, seen, func(h plumbing.Hash) error {
+			distance := count
+			if h == hash {
+				distance = 0
+			}
+			offsets[h] = distance + i
+			count++
+			newTags = newTags || tagged[h]
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to iterate commits: %w", err)
+		}
+
+		if newTags {
+			tag, ok := selectMostRecentTag(tags, tagPrefix, func(commit plumbing.Hash) bool { return seen[commit] })
+			latest = nil
+			if ok {
+				latest = &tag
+			}
+		}
+		g.graph.commitCounts[hash] = count
+		result := mostRecentTag{}
+		if latest != nil {
+			result = mostRecentTag{name: latest.name, distance: offsets[latest.commit] - i}
+		}
+		g.graph.mostRecentTags[mostRecentTagKey{commit: hash, tagPrefix: tagPrefix}] = result
+	}
+	return nil
+}
+
+// Ancestors returns the set of commits reachable from the given commit, including itself
+func (g *Repo) Ancestors(from plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	ancestors := make(map[plumbing.Hash]bool)
+	err := g.walk(from, func(hash plumbing.Hash) error {
+		ancestors[hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate commits: %w", err)
+	}
+	return ancestors, nil
+}
+
+// CommitsBetween returns the commits reachable from to but not from from, in git log order
+// A zero from returns the whole history of to.
+func (g *Repo) CommitsBetween(from, to plumbing.Hash) ([]plumbing.Hash, error) {
+	excluded := make(map[plumbing.Hash]bool)
+	if !from.IsZero() {
+		var err error
+		if excluded, err = g.Ancestors(from); err != nil {
+			return nil, err
+		}
+	}
+
+	var hashes []plumbing.Hash
+	err := g.walk(to, func(hash plumbing.Hash) error {
+		if !excluded[hash] {
+			hashes = append(hashes, hash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate commits: %w", err)
+	}
+	return hashes, nil
+}
+
+// ChangedFiles returns the paths a commit adds, modifies or deletes compared to its first parent
+// Renamed files are returned with their old and new path.
+func (g *Repo) ChangedFiles(hash plumbing.Hash) ([]string, error) {
+	commit, err := g.repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", hash, err)
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent of commit %s: %w", hash, err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to get tree of commit %s: %w", parent.Hash, err)
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff commit %s: %w", hash, err)
+	}
+	var files []string
+	for _, change := range changes {
+		if change.From.Name != "" {
+			files = append(files, change.From.Name)
+		}
+		if change.To.Name != "" && change.To.Name != change.From.Name {
+			files = append(files, change.To.Name)
+		}
+	}
+	return files, nil
+}
+
+// taggedCommits returns every tag in the repository along with the commit it points to
+// Annotated tags are resolved to their target commit. The list is read once and cached.
+func (g *Repo) taggedCommits() ([]taggedCommit, error) {
+	if g.graph.tagsLoaded {
+		return g.graph.tags, nil
+	}
+
+	tagRefs, err := g.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	var tags []taggedCommit
+	err = tagRefs.ForEach(func(ref *plumbing.Reference) error {
+		tagName := ref.Name().Short()
+
+		// Annotated tags point to a tag object, lightweight tags point directly to a commit
+		if tag, err := g.repo.TagObject(ref.Hash()); err == nil {
+			tags = append(tags, taggedCommit{name: tagName, commit: tag.Target})
+			return nil
+		}
+		tags = append(tags, taggedCommit{name: tagName, commit: ref.Hash()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate tags: %w", err)
+	}
+
+	g.graph.tags = tags
+	g.graph.tagsLoaded = true
+	return tags, nil
+}
+
+// invalidateTags drops the cached tags, so the next lookup sees tags created or moved since
+func (c *commitGraph) invalidateTags() {
+	c.tags = nil
+	c.tagsLoaded = false
+	c.mostRecentTags = make(map[mostRecentTagKey]mostRecentTag)
+}
+
+// Commit holds the metadata of a single commit
+type Commit struct {
+	Hash    plumbing.Hash
+	Author  string
+	Email   string
+	Date    time.Time
+	Message string
+	Parents []plumbing.Hash
+}
+
+// Subject returns the first line of the commit message
+func (c Commit) Subject() string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	return strings.TrimSpace(subject)
+}
+
+// GetCommit returns the metadata of the given commit
+func (g *Repo) GetCommit(hash plumbing.Hash) (Commit, error) {
+	commit, err := g.repo.CommitObject(hash)
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+	g.graph.parents[hash] = commit.ParentHashes
+
+	return Commit{
+		Hash:    commit.Hash,
+		Author:  commit.Author.Name,
+		Email:   commit.Author.Email,
+		Date:    commit.Author.When,
+		Message: commit.Message,
+		Parents: commit.ParentHashes,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// TagOptions configures the creation of an annotated tag
+type TagOptions struct {
+	Message string
+	Sign    bool // sign the tag with the signing key configured for git (user.signingkey, gpg.format)
+	Force   bool // replace an existing tag with the same name
+}
+
+// IsClean reports whether the worktree has no changes to tracked files
+// Untracked files are ignored.
+func (g *Repo) IsClean() (bool, error) {
+	worktree, err := g.repo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	for _, file := range status {
+		if file.Staging == git.Untracked && file.Worktree == git.Untracked {
+			continue
+		}
+		if file.Staging != git.Unmodified || file.Worktree != git.Unmodified {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// TagExists reports whether a tag with the given name exists
+func (g *Repo) TagExists(name string) (bool, error) {
+	_, err := g.repo.Tag(name)
+	if err == git.ErrTagNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up tag %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// ListTags returns the names of the tags that start with the given prefix, sorted by name
+func (g *Repo) ListTags(tagPrefix string) ([]string, error) {
+	tags, err := g.taggedCommits()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, tag := range tags {
+		if strings.HasPrefix(tag.name, tagPrefix) {
+			names = append(names, tag.name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateTag creates an annotated tag on a commit
+// Signed tags are created with the git command, so the signing configuration of git applies.
+func (g *Repo) CreateTag(name string, hash plumbing.Hash, opts TagOptions) error {
+	defer g.graph.invalidateTags()
+
+	if opts.Sign {
+		args := []string{"tag", "--annotate", "--sign", "--message", opts.Message}
+		if opts.Force {
+			args = append(args, "--force")
+		}
+		return g.runGit(append(args, name, hash.String())...)
+	}
+
+	tagger, err := g.signature()
+	if err != nil {
+		return err
+	}
+	if opts.Force {
+		if err := g.repo.DeleteTag(name); err != nil && err != git.ErrTagNotFound {
+			return fmt.Errorf("failed to delete existing tag %s: %w", name, err)
+		}
+	}
+	if _, err := g.repo.CreateTag(name, hash, &git.CreateTagOptions{Tagger: tagger, Message: opts.Message}); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// PushTag pushes a tag to a remote
+// The git command is used, so credential helpers and the SSH configuration of git apply.
+func (g *Repo) PushTag(remote, name string, force bool) error {
+	args := []string{"push", remote, "refs/tags/" + name}
+	if force {
+		args = append(args, "--force")
+	}
+	return g.runGit(args...)
+}
+
+// signature returns the identity tags are created with: GIT_COMMITTER_NAME and GIT_COMMITTER_EMAIL
+// if set, or else user.name and user.email from the git configuration
+func (g *Repo) signature() (*object.Signature, error) {
+	cfg, err := g.repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git configuration: %w", err)
+	}
+	name, email := cfg.User.Name, cfg.User.Email
+	if envName := os.Getenv("GIT_COMMITTER_NAME"); envName != "" {
+		name = envName
+	}
+	if envEmail := os.Getenv("GIT_COMMITTER_EMAIL"); envEmail != "" {
+		email = envEmail
+	}
+	if name == "" || email == "" {
+		return nil, fmt.Errorf("no identity to create the tag with: set user.name and user.email in the git configuration")
+	}
+	return &object.Signature{Name: name, Email: email, When: time.Now()}, nil
+}
+
+// runGit runs the git command in the repository
+func (g *Repo) runGit(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.path
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
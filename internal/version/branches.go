@@ -0,0 +1,84 @@
+package version
+
+import (
+	"fmt"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/git"
+)
+
+// BranchStatus is the calculated version of a branch tip and how far it has drifted from main
+type BranchStatus struct {
+	Branch       string `json:"branch"`
+	Ref          string `json:"ref"`
+	SHA          string `json:"sha"`
+	Version      string `json:"version"`
+	IsMainBranch bool   `json:"isMainBranch"`
+	Ahead        int    `json:"ahead"`
+	Behind       int    `json:"behind"`
+	NewTagOnMain string `json:"newTagOnMain,omitempty"`
+	Outdated     bool   `json:"outdated"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Branches calculates the version of every local and remote-tracking branch in one pass
+// Ahead and Behind are the number of commits on the branch and on main since the branch point,
+// and Outdated is the outdated base check of the calculation (see outdatedBaseCheckMode), so they
+// always match the version. Branches whose tip is tagged have no drift. Remote-tracking branches
+// that point to the same commit as an already listed branch of the same name are left out.
+func Branches(cfg *config.Config) ([]BranchStatus, error) {
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mainBranches := configuredMainBranches(cfg)
+	if _, err := repo.GetMainBranch(mainBranches); err != nil {
+		return nil, fmt.Errorf("failed to find main branch: %w", err)
+	}
+
+	branches, err := repo.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	// Outdated branches are reported, not treated as errors
+	overviewCfg := *cfg
+	failOnOutdated := false
+	overviewCfg.FailOnOutdatedBase = &failOnOutdated
+
+	log("Calculating versions for %d branch(es)...", len(branches))
+
+	seen := make(map[string]bool)
+	var statuses []BranchStatus
+	for _, b := range branches {
+		key := b.Name + "@" + b.Hash.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		status := BranchStatus{
+			Branch:       b.Name,
+			Ref:          b.RefName(),
+			SHA:          b.Hash.String(),
+			IsMainBranch: git.IsMainBranch(b.Name, mainBranches),
+		}
+
+		// The per-branch calculation details would drown out everything else
+		calc, err := calculateWithLog(repo, &overviewCfg, b.Hash, b.Hash.String(), b.Name, discardLog)
+		if err != nil {
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+		status.Version = calc.Version
+		status.Ahead = calc.BranchCommits
+		status.Behind = calc.MainCommitsSinceBranch
+		status.Outdated = calc.Outdated
+		status.NewTagOnMain = calc.NewTagOnMain
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
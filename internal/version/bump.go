@@ -0,0 +1,185 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/git"
+)
+
+// BumpResult is the version of an explicit release made with Bump
+type BumpResult struct {
+	Version string
+	BaseTag string     // tag the version was bumped from, empty when there are no tags
+	Warning string     // set when the version isn't greater than every existing tag
+	Tag     *TagResult // the created tag, nil when no tag was requested
+}
+
+// Bump increments the version of the most recent tag in the history of HEAD
+// part is "major", "minor", "patch" or "prerelease", label an optional prerelease label (see
+// BumpVersion). Without tags the version is bumped from 0.0.0. When tag is set, the version is
+// tagged on HEAD with the same checks as Tag.
+func Bump(cfg *config.Config, part, label string, tag *TagOptions) (*BumpResult, error) {
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.HeadHash()
+	if err != nil {
+		return nil, err
+	}
+	tagPrefix := ""
+	if cfg.TagPrefix != nil {
+		tagPrefix = *cfg.TagPrefix
+	}
+
+	baseTag, _, err := repo.GetMostRecentTag(head, tagPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get most recent tag: %w", err)
+	}
+	base := "0.0.0"
+	if baseTag != "" {
+		base = git.StripTagPrefix(baseTag, tagPrefix)
+		if !IsValidSemver(base) {
+			return nil, fmt.Errorf("most recent tag '%s' is not valid semver", baseTag)
+		}
+	}
+
+	tags, err := repo.ListTags(tagPrefix)
+	if err != nil {
+		return nil, err
+	}
+	// GetMostRecentTag doesn't order the tags of one release (1.3.0-rc.1, 1.3.0-rc.2, 1.3.0), so
+	// bump from the highest of them in the history of HEAD
+	for _, name := range tags {
+		v := git.StripTagPrefix(name, tagPrefix)
+		if baseTag == "" || !IsValidSemver(v) || semverCore(v) != semverCore(base) {
+			continue
+		}
+		if c, _ := CompareSemver(v, base); c <= 0 {
+			continue
+		}
+		if inHistory, err := repo.IsTagInHistory(head, name); err == nil && inHistory {
+			baseTag, base = name, v
+		}
+	}
+	log("Bumping %s of %s", part, base)
+
+	version, err := BumpVersion(base, part, label)
+	if err != nil {
+		return nil, err
+	}
+	result := &BumpResult{Version: version, BaseTag: baseTag}
+
+	// Warn about any tag, also on other branches, that the new version doesn't supersede
+	highest, highestTag := "", ""
+	for _, name := range tags {
+		v := git.StripTagPrefix(name, tagPrefix)
+		if !IsValidSemver(v) {
+			continue
+		}
+		if c, _ := CompareSemver(v, highest); highest == "" || c > 0 {
+			highest, highestTag = v, name
+		}
+	}
+	if highest != "" {
+		if c, _ := CompareSemver(version, highest); c <= 0 {
+			result.Warning = fmt.Sprintf("%s is not greater than the existing tag %s", version, highestTag)
+		}
+	}
+
+	if tag == nil {
+		return result, nil
+	}
+	branch, _, err := detectBranch(repo, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !git.IsMainBranch(branch, configuredMainBranches(cfg)) && !tag.Force {
+		return nil, fmt.Errorf("HEAD is on branch '%s', not a main branch %v (use --force to tag it anyway)", branch, configuredMainBranches(cfg))
+	}
+	result.Tag, err = tagCommit(repo, cfg, head.String(), version, *tag)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BumpVersion increments a semver version
+// "major", "minor" and "patch" increment that part and reset the lower ones. A prerelease of the
+// next version is released as it (2.0.0-rc.1 -> 2.0.0). With a label the increment becomes a
+// prerelease of the next version (1.2.3 minor rc -> 1.3.0-rc.1). "prerelease" increments the
+// number of a prerelease (1.3.0-rc.1 -> 1.3.0-rc.2), starts a prerelease with another label
+// (1.3.0-beta.2 rc -> 1.3.0-rc.1) or starts a prerelease of the next patch version
+// (1.2.3 -> 1.2.4-rc.1). Build metadata is dropped.
+func BumpVersion(base, part, label string) (string, error) {
+	parts := semverRegex.FindStringSubmatch(base)
+	if parts == nil {
+		return "", fmt.Errorf("invalid semver: %s", base)
+	}
+	major, _ := strconv.Atoi(parts[1])
+	minor, _ := strconv.Atoi(parts[2])
+	patch, _ := strconv.Atoi(parts[3])
+	prerelease := parts[4]
+
+	switch part {
+	case defaults.BumpMajor:
+		if label != "" || prerelease == "" || minor != 0 || patch != 0 {
+			major, minor, patch = major+1, 0, 0
+		}
+	case defaults.BumpMinor:
+		if label != "" || prerelease == "" || patch != 0 {
+			minor, patch = minor+1, 0
+		}
+	case defaults.BumpPatch:
+		if label != "" || prerelease == "" {
+			patch++
+		}
+	case defaults.BumpPrerelease:
+		number := 0
+		if prerelease == "" {
+			patch++
+			if label == "" {
+				label = defaults.DefaultBumpPrereleaseLabel
+			}
+		} else {
+			current := prerelease
+			if lastDot := strings.LastIndex(prerelease, "."); lastDot != -1 && isNumericIdentifier(prerelease[lastDot+1:]) {
+				current = prerelease[:lastDot]
+				number, _ = strconv.Atoi(prerelease[lastDot+1:])
+			}
+			if label == "" {
+				label = current
+			}
+			if label != current {
+				number = 0
+			}
+		}
+		return bumpedVersion(major, minor, patch, label, number+1)
+	default:
+		return "", fmt.Errorf("invalid increment '%s': must be one of %v", part, defaults.ValidBumpParts)
+	}
+	return bumpedVersion(major, minor, patch, label, 1)
+}
+
+// bumpedVersion formats a bumped version, a prerelease "<label>.<number>" when label is set
+func bumpedVersion(major, minor, patch int, label string, number int) (string, error) {
+	version := fmt.Sprintf("%d.%d.%d", major, minor, patch)
+	if label != "" {
+		version = fmt.Sprintf("%s-%s.%d", version, label, number)
+	}
+	if !IsValidSemver(version) {
+		return "", fmt.Errorf("invalid prerelease label '%s': must be dot-separated alphanumerics and hyphens", label)
+	}
+	return version, nil
+}
+
+// semverCore returns MAJOR.MINOR.PATCH of a semver version
+func semverCore(version string) string {
+	core, _, _ := strings.Cut(version, "+")
+	core, _, _ = strings.Cut(core, "-")
+	return core
+}
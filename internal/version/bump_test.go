@@ -0,0 +1,61 @@
+package version
+
+import "testing"
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		base     string
+		part     string
+		label    string
+		expected string
+	}{
+		{"1.2.3", "major", "", "2.0.0"},
+		{"1.2.3", "minor", "", "1.3.0"},
+		{"1.2.3", "patch", "", "1.2.4"},
+		{"1.2.3+build.5", "patch", "", "1.2.4"},
+		{"0.0.0", "minor", "", "0.1.0"},
+		{"1.2.3", "minor", "rc", "1.3.0-rc.1"},
+		{"1.2.3", "major", "beta", "2.0.0-beta.1"},
+		{"2.0.0-rc.2", "major", "", "2.0.0"},
+		{"1.3.0-rc.2", "major", "", "2.0.0"},
+		{"1.3.0-rc.2", "minor", "", "1.3.0"},
+		{"1.3.1-rc.2", "minor", "", "1.4.0"},
+		{"1.3.1-rc.2", "patch", "", "1.3.1"},
+		{"1.3.0-rc.2", "minor", "rc", "1.4.0-rc.1"},
+		{"1.2.3", "prerelease", "", "1.2.4-rc.1"},
+		{"1.2.3", "prerelease", "alpha", "1.2.4-alpha.1"},
+		{"1.3.0-rc.1", "prerelease", "", "1.3.0-rc.2"},
+		{"1.3.0-rc.9", "prerelease", "rc", "1.3.0-rc.10"},
+		{"1.3.0-beta.2", "prerelease", "rc", "1.3.0-rc.1"},
+		{"1.3.0-rc", "prerelease", "", "1.3.0-rc.1"},
+		{"1.3.0-x.y.3", "prerelease", "", "1.3.0-x.y.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base+" "+tt.part+" "+tt.label, func(t *testing.T) {
+			version, err := BumpVersion(tt.base, tt.part, tt.label)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, version)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		base  string
+		part  string
+		label string
+	}{
+		{"1.2", "patch", ""},
+		{"1.2.3", "build", ""},
+		{"1.2.3", "minor", "rc_1"},
+		{"1.2.3", "prerelease", "rc..1"},
+	}
+	for _, tt := range errorTests {
+		if _, err := BumpVersion(tt.base, tt.part, tt.label); err == nil {
+			t.Errorf("Expected error for %s %s %s", tt.base, tt.part, tt.label)
+		}
+	}
+}
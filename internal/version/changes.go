@@ -0,0 +1,122 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/git"
+)
+
+// ChangeSet is the commits of a release: the commits reachable from its commit but not from the
+// previous tag
+type ChangeSet struct {
+	Version         string // the version of the tag on the commit, or else the calculated version
+	Tag             string // tag on the commit, empty when it isn't tagged
+	PreviousVersion string // version of the previous tag, empty when there is none or from isn't a tag
+	PreviousTag     string // the previous tag or the from ref, empty when the whole history is included
+	SHA             string
+	Date            time.Time    // commit time of the commit
+	Commits         []git.Commit // newest first, without merge commits
+
+	repo *git.Repo
+}
+
+// ChangedFiles returns the paths changed by a commit of the change set
+func (c *ChangeSet) ChangedFiles(sha string) ([]string, error) {
+	return c.repo.ChangedFiles(plumbing.NewHash(sha))
+}
+
+// Changes returns the commits between two refs
+// to defaults to HEAD. from defaults to the most recent tag before to: when to is tagged, the
+// most recent tag in the history of its parents, so the commits of a tagged release are never
+// repeated in the next one. Merge commits are left out.
+func Changes(cfg *config.Config, from, to string) (*ChangeSet, error) {
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tagPrefix := ""
+	if cfg.TagPrefix != nil {
+		tagPrefix = *cfg.TagPrefix
+	}
+
+	var toHash plumbing.Hash
+	if to != "" {
+		toHash, err = repo.ResolveRef(to)
+	} else {
+		toHash, err = repo.HeadHash()
+	}
+	if err != nil {
+		return nil, err
+	}
+	calc, err := calculate(repo, cfg, toHash, to, "")
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.GetCommit(toHash)
+	if err != nil {
+		return nil, err
+	}
+	changes := &ChangeSet{Version: calc.Version, SHA: toHash.String(), Date: commit.Date, repo: repo}
+	if tag, err := repo.GetTagOnCommit(toHash, tagPrefix); err == nil && isVersionTag(tag, tagPrefix) {
+		changes.Tag = tag
+	}
+
+	var fromHash plumbing.Hash
+	if from != "" {
+		if fromHash, err = repo.ResolveRef(from); err != nil {
+			return nil, err
+		}
+		changes.PreviousTag = from
+	} else {
+		parents := []plumbing.Hash{toHash}
+		if changes.Tag != "" {
+			parents = commit.Parents
+		}
+		for _, parent := range parents {
+			tag, _, err := repo.GetMostRecentTag(parent, tagPrefix)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get most recent tag: %w", err)
+			}
+			if !isVersionTag(tag, tagPrefix) {
+				continue
+			}
+			if c, _ := CompareSemver(git.StripTagPrefix(tag, tagPrefix), git.StripTagPrefix(changes.PreviousTag, tagPrefix)); changes.PreviousTag == "" || c > 0 {
+				changes.PreviousTag = tag
+			}
+		}
+		if changes.PreviousTag != "" {
+			if fromHash, err = repo.ResolveRef(changes.PreviousTag); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if isVersionTag(changes.PreviousTag, tagPrefix) {
+		changes.PreviousVersion = git.StripTagPrefix(changes.PreviousTag, tagPrefix)
+	}
+	log("Collecting commits since %s", changes.PreviousTag)
+
+	hashes, err := repo.CommitsBetween(fromHash, toHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range hashes {
+		commit, err := repo.GetCommit(hash)
+		if err != nil {
+			return nil, err
+		}
+		if len(commit.Parents) > 1 {
+			continue
+		}
+		changes.Commits = append(changes.Commits, commit)
+	}
+	return changes, nil
+}
+
+// isVersionTag reports whether a tag has the tag prefix and a valid semver version after it
+func isVersionTag(tag, tagPrefix string) bool {
+	return tag != "" && strings.HasPrefix(tag, tagPrefix) && IsValidSemver(git.StripTagPrefix(tag, tagPrefix))
+}
@@ -0,0 +1,109 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+)
+
+// dockerTagMaxLength is the maximum length of an OCI image tag
+const dockerTagMaxLength = 128
+
+// Standard OCI image annotations, see https://github.com/opencontainers/image-spec/blob/main/annotations.md
+const (
+	ociVersionLabel  = "org.opencontainers.image.version"
+	ociRevisionLabel = "org.opencontainers.image.revision"
+)
+
+// dockerTagInvalidChars matches characters that aren't allowed in OCI image tags
+var dockerTagInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// DockerMetadata is the tag list and labels of a container image
+type DockerMetadata struct {
+	Tags   []string          `json:"tags"`
+	Labels map[string]string `json:"labels"`
+}
+
+// NewDockerMetadata returns the image tags and OCI labels for a version result
+// Releases are tagged with the full version and the configured floating tags: "1.2" (minor), "1"
+// (major) and "latest" (releases on the main branch only). Other versions are tagged with the
+// sanitized version and the floating "branch-<name>" tag. Tags are prefixed with "image:" for every
+// image given.
+func NewDockerMetadata(result *Result, cfg *config.Config, images []string) (DockerMetadata, error) {
+	floating, err := dockerFloatingTags(cfg)
+	if err != nil {
+		return DockerMetadata{}, err
+	}
+
+	tags := []string{sanitizeDockerTag(result.Semver)}
+	if result.IsRelease {
+		if floating[defaults.DockerFloatingTagMinor] {
+			tags = append(tags, fmt.Sprintf("%d.%d", result.Major, result.Minor))
+		}
+		if floating[defaults.DockerFloatingTagMajor] {
+			tags = append(tags, fmt.Sprintf("%d", result.Major))
+		}
+		if floating[defaults.DockerFloatingTagLatest] && result.IsMainBranch {
+			tags = append(tags, "latest")
+		}
+	} else if floating[defaults.DockerFloatingTagBranch] && result.SanitizedBranch != "" {
+		tags = append(tags, sanitizeDockerTag("branch-"+result.SanitizedBranch))
+	}
+
+	if len(images) > 0 {
+		var imageTags []string
+		for _, image := range images {
+			for _, tag := range tags {
+				imageTags = append(imageTags, image+":"+tag)
+			}
+		}
+		tags = imageTags
+	}
+
+	return DockerMetadata{
+		Tags: tags,
+		Labels: map[string]string{
+			ociVersionLabel:  result.Semver,
+			ociRevisionLabel: result.SHA,
+		},
+	}, nil
+}
+
+// dockerFloatingTags returns the enabled floating tags
+func dockerFloatingTags(cfg *config.Config) (map[string]bool, error) {
+	names := defaults.DefaultDockerFloatingTags
+	if cfg.DockerFloatingTags != nil {
+		names = cfg.DockerFloatingTags
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range names {
+		valid := false
+		for _, validName := range defaults.ValidDockerFloatingTags {
+			if name == validName {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid dockerFloatingTags entry '%s': must be one of %v", name, defaults.ValidDockerFloatingTags)
+		}
+		enabled[name] = true
+	}
+	return enabled, nil
+}
+
+// sanitizeDockerTag makes a string a valid OCI image tag: characters other than letters, digits,
+// "_", "." and "-" (such as the "+" of semver build metadata) become "-", it can't start with "."
+// or "-", and it is at most 128 characters long
+func sanitizeDockerTag(tag string) string {
+	tag = dockerTagInvalidChars.ReplaceAllString(tag, "-")
+	tag = strings.TrimLeft(tag, ".-")
+	if len(tag) > dockerTagMaxLength {
+		tag = tag[:dockerTagMaxLength]
+	}
+	return tag
+}
@@ -0,0 +1,105 @@
+package version
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+)
+
+func TestNewDockerMetadata(t *testing.T) {
+	sha := "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name     string
+		result   Result
+		cfg      config.Config
+		images   []string
+		expected []string
+	}{
+		{
+			name:     "release on main",
+			result:   Result{Semver: "1.2.3", Major: 1, Minor: 2, Patch: 3, IsRelease: true, IsMainBranch: true},
+			expected: []string{"1.2.3", "1.2", "1", "latest"},
+		},
+		{
+			name:     "release off main",
+			result:   Result{Semver: "1.2.3", Major: 1, Minor: 2, Patch: 3, IsRelease: true},
+			expected: []string{"1.2.3", "1.2", "1"},
+		},
+		{
+			name:     "feature branch",
+			result:   Result{Semver: "1.2.4-login-x.3", Major: 1, Minor: 2, Patch: 4, SanitizedBranch: "login-x"},
+			expected: []string{"1.2.4-login-x.3", "branch-login-x"},
+		},
+		{
+			name:     "build metadata",
+			result:   Result{Semver: "1.2.4-pre.3+build.7", Major: 1, Minor: 2, Patch: 4, SanitizedBranch: "main"},
+			expected: []string{"1.2.4-pre.3-build.7", "branch-main"},
+		},
+		{
+			name:     "configured floating tags",
+			result:   Result{Semver: "1.2.3", Major: 1, Minor: 2, Patch: 3, IsRelease: true, IsMainBranch: true},
+			cfg:      config.Config{DockerFloatingTags: []string{"major"}},
+			expected: []string{"1.2.3", "1"},
+		},
+		{
+			name:     "no floating tags",
+			result:   Result{Semver: "1.2.4-login-x.3", Major: 1, Minor: 2, Patch: 4, SanitizedBranch: "login-x"},
+			cfg:      config.Config{DockerFloatingTags: []string{}},
+			expected: []string{"1.2.4-login-x.3"},
+		},
+		{
+			name:     "images",
+			result:   Result{Semver: "1.2.3", Major: 1, Minor: 2, Patch: 3, IsRelease: true},
+			images:   []string{"ghcr.io/org/app", "app"},
+			expected: []string{"ghcr.io/org/app:1.2.3", "ghcr.io/org/app:1.2", "ghcr.io/org/app:1", "app:1.2.3", "app:1.2", "app:1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.result.SHA = sha
+			metadata, err := NewDockerMetadata(&tt.result, &tt.cfg, tt.images)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(metadata.Tags, tt.expected) {
+				t.Errorf("Expected tags %v, got %v", tt.expected, metadata.Tags)
+			}
+			if metadata.Labels["org.opencontainers.image.version"] != tt.result.Semver {
+				t.Errorf("Expected version label %s, got %s", tt.result.Semver, metadata.Labels["org.opencontainers.image.version"])
+			}
+			if metadata.Labels["org.opencontainers.image.revision"] != sha {
+				t.Errorf("Expected revision label %s, got %s", sha, metadata.Labels["org.opencontainers.image.revision"])
+			}
+		})
+	}
+
+	invalid := config.Config{DockerFloatingTags: []string{"edge"}}
+	if _, err := NewDockerMetadata(&Result{Semver: "1.0.0"}, &invalid, nil); err == nil {
+		t.Errorf("Expected error for an invalid dockerFloatingTags entry")
+	}
+}
+
+func TestSanitizeDockerTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"1.2.3+build.5", "1.2.3-build.5"},
+		{"branch-feature_x", "branch-feature_x"},
+		{".-leading", "leading"},
+		{strings.Repeat("a", 200), strings.Repeat("a", 128)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if tag := sanitizeDockerTag(tt.tag); tag != tt.expected {
+				t.Errorf("sanitizeDockerTag(%q) = %q, want %q", tt.tag, tag, tt.expected)
+			}
+		})
+	}
+}
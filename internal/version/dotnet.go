@@ -0,0 +1,130 @@
+package version
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+)
+
+// dotnetMaxVersionPart is the largest number allowed in AssemblyVersion and FileVersion
+// (the parts are 16 bit and 65535 is reserved)
+const dotnetMaxVersionPart = 65534
+
+// dotnetPropsMarker marks a Directory.Build.props file as generated, so it can be overwritten
+const dotnetPropsMarker = "<!-- Generated by autoversion. Changes will be overwritten. -->"
+
+// DotnetVersions are the versions of a .NET assembly and NuGet package
+type DotnetVersions struct {
+	AssemblyVersion      string `json:"assemblyVersion"`      // Major.0.0.0 or Major.Minor.0.0
+	FileVersion          string `json:"fileVersion"`          // Major.Minor.Patch.Build
+	InformationalVersion string `json:"informationalVersion"` // full semver with the commit SHA as build metadata
+	NuGetVersion         string `json:"nugetVersion"`         // package version with a SemVer 1.0 prerelease label
+}
+
+// NewDotnetVersions converts a version result to the .NET versions
+func NewDotnetVersions(result *Result, cfg *config.Config) (DotnetVersions, error) {
+	assemblyVersionStyle := defaults.DefaultDotnetAssemblyVersion
+	if cfg.DotnetAssemblyVersion != nil && *cfg.DotnetAssemblyVersion != "" {
+		assemblyVersionStyle = *cfg.DotnetAssemblyVersion
+	}
+
+	for _, part := range []int{result.Major, result.Minor, result.Patch, result.Build} {
+		if part > dotnetMaxVersionPart {
+			return DotnetVersions{}, fmt.Errorf("cannot convert %s to a .NET file version: %d is larger than %d", result.Semver, part, dotnetMaxVersionPart)
+		}
+	}
+
+	var versions DotnetVersions
+	switch assemblyVersionStyle {
+	case defaults.DotnetAssemblyVersionMajor:
+		versions.AssemblyVersion = fmt.Sprintf("%d.0.0.0", result.Major)
+	case defaults.DotnetAssemblyVersionMinor:
+		versions.AssemblyVersion = fmt.Sprintf("%d.%d.0.0", result.Major, result.Minor)
+	default:
+		return DotnetVersions{}, fmt.Errorf("invalid dotnetAssemblyVersion '%s': must be one of %v", assemblyVersionStyle, defaults.ValidDotnetAssemblyVersions)
+	}
+	versions.FileVersion = fmt.Sprintf("%d.%d.%d.%d", result.Major, result.Minor, result.Patch, result.Build)
+
+	versions.InformationalVersion = result.Semver
+	if result.SHA != "" {
+		separator := "+"
+		if strings.Contains(result.Semver, "+") {
+			separator = "."
+		}
+		versions.InformationalVersion += separator + result.SHA
+	}
+
+	versions.NuGetVersion = fmt.Sprintf("%d.%d.%d", result.Major, result.Minor, result.Patch)
+	if !result.IsRelease {
+		versions.NuGetVersion += "-" + legacyPrereleaseLabel(result.Prerelease, result.Build)
+	}
+	return versions, nil
+}
+
+// legacyPrereleaseLabel returns a prerelease label that old NuGet clients handle: SemVer 1.0 (letters,
+// digits and hyphens, starting with a letter), at most 20 characters, and with a zero-padded build
+// number because the labels are compared as text ("login-x.3" -> "login-x0003")
+func legacyPrereleaseLabel(label string, build int) string {
+	label = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, label)
+	if label == "" || !(label[0] >= 'a' && label[0] <= 'z' || label[0] >= 'A' && label[0] <= 'Z') {
+		label = defaults.PrereleaseID + label
+	}
+
+	number := fmt.Sprintf("%0*d", defaults.DotnetNuGetBuildPadding, build)
+	if maxLabel := defaults.DotnetNuGetMaxPrerelease - len(number); len(label) > maxLabel {
+		label = strings.TrimRight(label[:maxLabel], "-")
+	}
+	return label + number
+}
+
+// DotnetProps returns a Directory.Build.props file that sets the versions for every project below it
+// The SDK would add the commit SHA to InformationalVersion a second time, so that is turned off.
+func DotnetProps(versions DotnetVersions) string {
+	var b strings.Builder
+	b.WriteString(dotnetPropsMarker + "\n")
+	b.WriteString("<Project>\n")
+	b.WriteString("  <PropertyGroup>\n")
+	for _, property := range []struct{ name, value string }{
+		{"Version", versions.NuGetVersion},
+		{"PackageVersion", versions.NuGetVersion},
+		{"AssemblyVersion", versions.AssemblyVersion},
+		{"FileVersion", versions.FileVersion},
+		{"InformationalVersion", versions.InformationalVersion},
+		{"IncludeSourceRevisionInInformationalVersion", "false"},
+	} {
+		var value bytes.Buffer
+		xml.EscapeText(&value, []byte(property.value))
+		fmt.Fprintf(&b, "    <%s>%s</%s>\n", property.name, value.String(), property.name)
+	}
+	b.WriteString("  </PropertyGroup>\n")
+	b.WriteString("</Project>\n")
+	return b.String()
+}
+
+// WriteDotnetProps writes the versions to a Directory.Build.props file
+// Existing files are only overwritten when autoversion generated them.
+func WriteDotnetProps(path string, versions DotnetVersions) error {
+	existing, err := os.ReadFile(path)
+	if err == nil && !strings.HasPrefix(string(existing), dotnetPropsMarker) {
+		return fmt.Errorf("refusing to overwrite %s, which wasn't generated by autoversion", path)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, []byte(DotnetProps(versions)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	log("Wrote .NET versions to %s", path)
+	return nil
+}
@@ -0,0 +1,137 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+)
+
+func TestNewDotnetVersions(t *testing.T) {
+	sha := "0123456789abcdef0123456789abcdef01234567"
+	minor := "minor"
+
+	tests := []struct {
+		name     string
+		result   Result
+		cfg      config.Config
+		expected DotnetVersions
+	}{
+		{
+			name:   "release",
+			result: Result{Semver: "1.2.3", Major: 1, Minor: 2, Patch: 3, IsRelease: true, SHA: sha},
+			expected: DotnetVersions{
+				AssemblyVersion:      "1.0.0.0",
+				FileVersion:          "1.2.3.0",
+				InformationalVersion: "1.2.3+" + sha,
+				NuGetVersion:         "1.2.3",
+			},
+		},
+		{
+			name:   "feature branch with minor assembly version",
+			result: Result{Semver: "1.2.4-login-x.3", Major: 1, Minor: 2, Patch: 4, Prerelease: "login-x", Build: 3, SHA: sha},
+			cfg:    config.Config{DotnetAssemblyVersion: &minor},
+			expected: DotnetVersions{
+				AssemblyVersion:      "1.2.0.0",
+				FileVersion:          "1.2.4.3",
+				InformationalVersion: "1.2.4-login-x.3+" + sha,
+				NuGetVersion:         "1.2.4-login-x0003",
+			},
+		},
+		{
+			name:   "build metadata",
+			result: Result{Semver: "2.0.0+build.7", Major: 2, IsRelease: true, SHA: sha},
+			expected: DotnetVersions{
+				AssemblyVersion:      "2.0.0.0",
+				FileVersion:          "2.0.0.0",
+				InformationalVersion: "2.0.0+build.7." + sha,
+				NuGetVersion:         "2.0.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions, err := NewDotnetVersions(&tt.result, &tt.cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if versions != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, versions)
+			}
+		})
+	}
+
+	invalid := "patch"
+	if _, err := NewDotnetVersions(&Result{Semver: "1.0.0"}, &config.Config{DotnetAssemblyVersion: &invalid}); err == nil {
+		t.Errorf("Expected error for an invalid dotnetAssemblyVersion")
+	}
+	if _, err := NewDotnetVersions(&Result{Semver: "1.0.70000", Major: 1, Patch: 70000}, &config.Config{}); err == nil {
+		t.Errorf("Expected error for a version part larger than 65534")
+	}
+}
+
+func TestLegacyPrereleaseLabel(t *testing.T) {
+	tests := []struct {
+		label    string
+		build    int
+		expected string
+	}{
+		{"pre", 5, "pre0005"},
+		{"rc", 1, "rc0001"},
+		{"123-fix", 2, "pre123-fix0002"},
+		{"a-very-long-branch-name", 12, "a-very-long-bran0012"},
+		{"abcdefghijklmno-pq", 1, "abcdefghijklmno0001"},
+		{"rc.1", 0, "rc-10000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			label := legacyPrereleaseLabel(tt.label, tt.build)
+			if label != tt.expected {
+				t.Errorf("legacyPrereleaseLabel(%q, %d) = %q, want %q", tt.label, tt.build, label, tt.expected)
+			}
+			if len(label) > 20 {
+				t.Errorf("Label %q is longer than 20 characters", label)
+			}
+		})
+	}
+}
+
+func TestWriteDotnetProps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Directory.Build.props")
+	versions := DotnetVersions{AssemblyVersion: "1.0.0.0", FileVersion: "1.2.4.3", InformationalVersion: "1.2.4-x.3+abc", NuGetVersion: "1.2.4-x0003"}
+
+	if err := WriteDotnetProps(path, versions); err != nil {
+		t.Fatalf("WriteDotnetProps failed: %v", err)
+	}
+	// A generated file can be written again
+	if err := WriteDotnetProps(path, versions); err != nil {
+		t.Fatalf("WriteDotnetProps failed to overwrite its own file: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read props file: %v", err)
+	}
+	for _, expected := range []string{
+		"<Version>1.2.4-x0003</Version>",
+		"<AssemblyVersion>1.0.0.0</AssemblyVersion>",
+		"<FileVersion>1.2.4.3</FileVersion>",
+		"<InformationalVersion>1.2.4-x.3+abc</InformationalVersion>",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected %s in props file:\n%s", expected, content)
+		}
+	}
+
+	// Files that autoversion didn't write are left alone
+	if err := os.WriteFile(path, []byte("<Project />\n"), 0644); err != nil {
+		t.Fatalf("Failed to write props file: %v", err)
+	}
+	if err := WriteDotnetProps(path, versions); err == nil {
+		t.Errorf("Expected error when overwriting a file autoversion didn't generate")
+	}
+}
@@ -0,0 +1,138 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+	"github.com/trondhindenes/autoversion/internal/git"
+)
+
+// FindResult is a commit whose calculated version matches a searched version
+type FindResult struct {
+	SHA     string    `json:"sha"`
+	Branch  string    `json:"branch"`
+	BaseTag string    `json:"baseTag"`
+	Version string    `json:"version"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+}
+
+// Find returns the commits that produce exactly the given version under the current configuration
+// The main branch history is searched first, followed by the local and remote-tracking branches.
+// For prerelease versions only the branches whose sanitized name matches the prerelease label
+// are searched. The version may include the configured versionPrefix.
+func Find(cfg *config.Config, target string) ([]FindResult, error) {
+	if cfg.VersionPrefix != nil && *cfg.VersionPrefix != "" {
+		target = strings.TrimPrefix(target, *cfg.VersionPrefix)
+	}
+	if !IsValidSemver(target) {
+		return nil, fmt.Errorf("'%s' is not a valid semver version", target)
+	}
+
+	// Prerelease versions are "<label>.<build>", where the label is the sanitized branch name
+	// (or the prerelease identifier for the main branch in "pre" mode)
+	label := ""
+	if _, prerelease, found := strings.Cut(target, "-"); found {
+		label = prerelease
+		if lastDot := strings.LastIndex(prerelease, "."); lastDot != -1 {
+			label = prerelease[:lastDot]
+		}
+	}
+
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mainBranches := configuredMainBranches(cfg)
+	mainBranch, err := repo.GetMainBranch(mainBranches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find main branch: %w", err)
+	}
+	mainHash, err := repo.ResolveMainBranch(mainBranch)
+	if err != nil {
+		return nil, err
+	}
+	onMain, err := repo.Ancestors(mainHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the branches that could have produced the version
+	type search struct {
+		branch string
+		tip    plumbing.Hash
+	}
+	var searches []search
+	if label == "" || label == defaults.PrereleaseID {
+		searches = append(searches, search{branch: mainBranch, tip: mainHash})
+	}
+	branches, err := repo.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range branches {
+		if git.IsMainBranch(b.Name, mainBranches) {
+			continue
+		}
+		if label != "" && git.SanitizeBranchName(b.Name) != label {
+			continue
+		}
+		searches = append(searches, search{branch: b.Name, tip: b.Hash})
+	}
+	log("Searching %d branch(es) for version %s...", len(searches), target)
+
+	var results []FindResult
+	var skipped []string
+	checked := make(map[string]bool)
+searchLoop:
+	for _, s := range searches {
+		hashes, err := branchHistory(repo, s.tip, s.branch, mainBranch, mainBranches, onMain)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hash := range hashes {
+			// A local branch and its remote-tracking branch share most of their commits
+			key := s.branch + "@" + hash.String()
+			if checked[key] {
+				continue
+			}
+			checked[key] = true
+
+			// The per-commit calculation details would drown out everything else
+			calc, err := calculateWithLog(repo, cfg, hash, hash.String(), s.branch, discardLog)
+			if err != nil {
+				// A branch that can't be versioned (e.g. failOnOutdatedBase) shouldn't end the search
+				skipped = append(skipped, fmt.Sprintf("%s: %v", s.branch, err))
+				continue searchLoop
+			}
+			if calc.Version != target {
+				continue
+			}
+
+			commit, err := repo.GetCommit(hash)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, FindResult{
+				SHA:     hash.String(),
+				Branch:  s.branch,
+				BaseTag: calc.BaseTag,
+				Version: calc.Version,
+				Date:    commit.Date,
+				Subject: commit.Subject(),
+			})
+		}
+	}
+
+	for _, reason := range skipped {
+		log("WARNING: skipped branch %s", reason)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,123 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+)
+
+// chartVersionLine matches a top-level version or appVersion line of a Chart.yaml, keeping the
+// quotes of the value and any trailing comment
+var chartVersionLine = regexp.MustCompile(`^(version|appVersion)(\s*:\s*)("[^"]*"|'[^']*'|[^\s#]*)(\s*(?:#.*)?\r?)$`)
+
+// HelmVersions are the versions of a Helm chart
+type HelmVersions struct {
+	Version    string `json:"version"`    // chart version, strict semver
+	AppVersion string `json:"appVersion"` // version of the application the chart deploys
+}
+
+// NewHelmVersions returns the chart version and appVersion of a commit
+// The two versions are calculated from the tags with helmChartTagPrefix and helmAppVersionTagPrefix
+// (e.g. "chart-1.0.3" and "v2.4.0"). Either defaults to tagPrefix, in which case result is used.
+func NewHelmVersions(result *Result, cfg *config.Config) (HelmVersions, error) {
+	chart, err := resultForTagPrefix(result, cfg, cfg.HelmChartTagPrefix)
+	if err != nil {
+		return HelmVersions{}, fmt.Errorf("failed to calculate the chart version: %w", err)
+	}
+	app, err := resultForTagPrefix(result, cfg, cfg.HelmAppVersionTagPrefix)
+	if err != nil {
+		return HelmVersions{}, fmt.Errorf("failed to calculate the appVersion: %w", err)
+	}
+
+	if !IsValidSemver(chart.Semver) {
+		return HelmVersions{}, fmt.Errorf("chart version %s is not valid semver", chart.Semver)
+	}
+	return HelmVersions{Version: chart.Semver, AppVersion: app.Semver}, nil
+}
+
+// resultForTagPrefix calculates the version of the same commit as result from the tags with a
+// different tag prefix, reusing the repository, branch and commit counts of its calculation.
+// Returns result itself if tagPrefix is nil or unchanged.
+func resultForTagPrefix(result *Result, cfg *config.Config, tagPrefix *string) (*Result, error) {
+	if tagPrefix == nil || (cfg.TagPrefix != nil && *tagPrefix == *cfg.TagPrefix) || (cfg.TagPrefix == nil && *tagPrefix == "") {
+		return result, nil
+	}
+	if result.calc == nil {
+		return nil, fmt.Errorf("no calculation to take the tags with prefix '%s' into account", *tagPrefix)
+	}
+
+	prefixed := *cfg
+	prefixed.TagPrefix = tagPrefix
+	log("Calculating version from tags with prefix '%s'", *tagPrefix)
+	calc, err := result.calc.forTagPrefix(&prefixed, *tagPrefix, log)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(calc, &prefixed)
+}
+
+// UpdateChartFile writes the versions to the top-level version and appVersion fields of a Chart.yaml
+// Only the values are replaced, so comments, quoting and the rest of the file are kept. appVersion
+// is added after version if the chart doesn't have one.
+func UpdateChartFile(path string, versions HelmVersions) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	updated, err := UpdateChartVersions(string(content), versions)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(updated), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	log("Wrote chart version %s and appVersion %s to %s", versions.Version, versions.AppVersion, path)
+	return nil
+}
+
+// UpdateChartVersions replaces the version and appVersion values in the content of a Chart.yaml
+// like UpdateChartFile
+func UpdateChartVersions(content string, versions HelmVersions) (string, error) {
+	lines := strings.Split(content, "\n")
+	versionLine, hasAppVersion := -1, false
+	for i, line := range lines {
+		match := chartVersionLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		key, separator, value, rest := match[1], match[2], match[3], match[4]
+
+		newValue := versions.Version
+		if key == "appVersion" {
+			newValue = versions.AppVersion
+			hasAppVersion = true
+		} else {
+			versionLine = i
+		}
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			newValue = value[:1] + newValue + value[:1]
+		}
+		lines[i] = key + separator + newValue + rest
+	}
+
+	if versionLine == -1 {
+		return "", fmt.Errorf("no top-level version field found")
+	}
+	if !hasAppVersion {
+		lineEnd := ""
+		if strings.HasSuffix(lines[versionLine], "\r") {
+			lineEnd = "\r"
+		}
+		appVersionLine := fmt.Sprintf("appVersion: %q%s", versions.AppVersion, lineEnd)
+		lines = append(lines[:versionLine+1], append([]string{appVersionLine}, lines[versionLine+1:]...)...)
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,84 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateChartVersions(t *testing.T) {
+	versions := HelmVersions{Version: "1.0.4", AppVersion: "2.5.1"}
+
+	tests := []struct {
+		name     string
+		chart    string
+		expected string
+	}{
+		{
+			name: "comments and quotes are kept",
+			chart: `# Chart for the app
+apiVersion: v2
+name: app
+version: 1.0.0 # bumped by autoversion
+appVersion: "2.0.0"
+dependencies:
+  - name: redis
+    version: 17.0.0
+`,
+			expected: `# Chart for the app
+apiVersion: v2
+name: app
+version: 1.0.4 # bumped by autoversion
+appVersion: "2.5.1"
+dependencies:
+  - name: redis
+    version: 17.0.0
+`,
+		},
+		{
+			name:     "single quotes",
+			chart:    "version: '1.0.0'\nappVersion:   '2.0.0'\n",
+			expected: "version: '1.0.4'\nappVersion:   '2.5.1'\n",
+		},
+		{
+			name:     "appVersion is added",
+			chart:    "name: app\r\nversion: 0.1.0\r\ntype: application\r\n",
+			expected: "name: app\r\nversion: 1.0.4\r\nappVersion: \"2.5.1\"\r\ntype: application\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := UpdateChartVersions(tt.chart, versions)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if updated != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, updated)
+			}
+		})
+	}
+
+	if _, err := UpdateChartVersions("name: app\n", versions); err == nil {
+		t.Errorf("Expected error for a chart without version")
+	}
+}
+
+func TestUpdateChartFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Chart.yaml")
+	if err := os.WriteFile(path, []byte("apiVersion: v2\nname: app\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write chart: %v", err)
+	}
+
+	if err := UpdateChartFile(path, HelmVersions{Version: "1.0.4", AppVersion: "2.5.1"}); err != nil {
+		t.Fatalf("UpdateChartFile failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read chart: %v", err)
+	}
+	expected := "apiVersion: v2\nname: app\nversion: 1.0.4\nappVersion: \"2.5.1\"\n"
+	if string(content) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, content)
+	}
+}
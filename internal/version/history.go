@@ -0,0 +1,159 @@
+package version
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/git"
+)
+
+// HistoryEntry is the calculated version of a single commit in a branch's history
+type HistoryEntry struct {
+	SHA     string    `json:"sha"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Version string    `json:"version"`
+}
+
+// History calculates the version of every commit on a branch's first-parent history, newest first
+// For branches other than the main branch, only the commits made since the branch diverged from
+// main are included. An empty branch means the current branch. limit caps the number of
+// commits (0 means no limit).
+// The history is walked once up front to find the most recent tag and commit count of every
+// commit, so the calculations don't each walk the whole history again.
+func History(cfg *config.Config, branch string, limit int) ([]HistoryEntry, error) {
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if branch == "" {
+		branch, _, err = detectBranch(repo, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mainBranches := configuredMainBranches(cfg)
+	mainBranch, err := repo.GetMainBranch(mainBranches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find main branch: %w", err)
+	}
+
+	resolveTip := repo.ResolveBranch
+	if git.IsMainBranch(branch, mainBranches) {
+		resolveTip = repo.ResolveMainBranch
+	}
+	tip, err := resolveTip(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, err := branchHistory(repo, tip, branch, mainBranch, mainBranches, nil)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(hashes) > limit {
+		hashes = hashes[:limit]
+	}
+	log("Calculating versions for %d commit(s) on %s...", len(hashes), branch)
+
+	tagPrefix := ""
+	if cfg.TagPrefix != nil {
+		tagPrefix = *cfg.TagPrefix
+	}
+	if err := repo.IndexHistory(hashes, tagPrefix); err != nil {
+		return nil, fmt.Errorf("failed to walk history of %s: %w", branch, err)
+	}
+
+	entries := make([]HistoryEntry, 0, len(hashes))
+	for _, hash := range hashes {
+		// The per-commit calculation details would drown out everything else
+		calc, err := calculateWithLog(repo, cfg, hash, hash.String(), branch, discardLog)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate version for %s: %w", hash.String(), err)
+		}
+
+		commit, err := repo.GetCommit(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, HistoryEntry{
+			SHA:     hash.String(),
+			Date:    commit.Date,
+			Subject: commit.Subject(),
+			Version: calc.Version,
+		})
+	}
+
+	return entries, nil
+}
+
+// branchHistory returns the first-parent history of a branch tip, newest first
+// For branches other than the main branch, only the commits since the branch diverged from main
+// are returned. onMain is the set of commits reachable from the main branch; it is computed when nil.
+func branchHistory(repo *git.Repo, tip plumbing.Hash, branch, mainBranch string, mainBranches []string, onMain map[plumbing.Hash]bool) ([]plumbing.Hash, error) {
+	// Feature branch history ends where the branch diverged from main
+	var stop func(hash plumbing.Hash) bool
+	if !git.IsMainBranch(branch, mainBranches) {
+		if onMain == nil {
+			mainHash, err := repo.ResolveMainBranch(mainBranch)
+			if err != nil {
+				return nil, err
+			}
+			onMain, err = repo.Ancestors(mainHash)
+			if err != nil {
+				return nil, err
+			}
+		}
+		stop = func(hash plumbing.Hash) bool { return onMain[hash] }
+	}
+
+	hashes, err := repo.FirstParents(tip, stop)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk history of %s: %w", branch, err)
+	}
+	return hashes, nil
+}
+
+// AuditHistory checks that versions in a history (newest first, as returned by History) always
+// increase from older to newer commits and that no two commits share a version
+// It returns a description of each problem found.
+func AuditHistory(entries []HistoryEntry) []string {
+	var problems []string
+	seen := make(map[string]string)
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if previousSHA, exists := seen[entry.Version]; exists {
+			problems = append(problems, fmt.Sprintf("%s and %s both have version %s", shortSHA(previousSHA), shortSHA(entry.SHA), entry.Version))
+		}
+		seen[entry.Version] = entry.SHA
+
+		if i == len(entries)-1 {
+			continue
+		}
+		older := entries[i+1]
+		cmp, err := CompareSemver(entry.Version, older.Version)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("cannot compare %s (%s) with %s (%s): %v", shortSHA(entry.SHA), entry.Version, shortSHA(older.SHA), older.Version, err))
+			continue
+		}
+		if cmp < 0 {
+			problems = append(problems, fmt.Sprintf("version went backwards from %s (%s) to %s (%s)", older.Version, shortSHA(older.SHA), entry.Version, shortSHA(entry.SHA)))
+		}
+	}
+
+	return problems
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
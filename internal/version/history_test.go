@@ -0,0 +1,47 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestAuditHistory(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string // newest first
+		problems int
+	}{
+		{
+			name:     "always increasing",
+			versions: []string{"1.0.2", "1.0.1", "1.0.0"},
+			problems: 0,
+		},
+		{
+			name:     "prerelease builds increasing",
+			versions: []string{"1.0.1-pre.1", "1.0.1-pre.0", "1.0.0"},
+			problems: 0,
+		},
+		{
+			name:     "duplicate version",
+			versions: []string{"1.0.1", "1.0.1", "1.0.0"},
+			problems: 1,
+		},
+		{
+			name:     "version went backwards",
+			versions: []string{"1.0.1", "2.0.0", "1.0.0"},
+			problems: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []HistoryEntry
+			for i, v := range tt.versions {
+				entries = append(entries, HistoryEntry{SHA: string(rune('a' + i)), Version: v})
+			}
+			problems := AuditHistory(entries)
+			if len(problems) != tt.problems {
+				t.Errorf("AuditHistory(%v) found %d problems %v, want %d", tt.versions, len(problems), problems, tt.problems)
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package version
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
 )
 
 // TestIntegration runs comprehensive integration tests with real git repositories
@@ -32,6 +36,20 @@ func TestIntegration(t *testing.T) {
 	t.Run("TagPrefixFiltering", testTagPrefixFiltering)
 	t.Run("MainBranchBehaviorPreWithTagNotInHistory", testMainBranchBehaviorPreWithTagNotInHistory)
 	t.Run("MultipleTagsHighestVersion", testMultipleTagsHighestVersion)
+	t.Run("CalculateForRef", testCalculateForRef)
+	t.Run("History", testHistory)
+	t.Run("Find", testFind)
+	t.Run("Branches", testBranches)
+	t.Run("ConfigurableRemote", testConfigurableRemote)
+	t.Run("MainBranchDivergence", testMainBranchDivergence)
+	t.Run("OutputTemplate", testOutputTemplate)
+	t.Run("JSONOutputProvenance", testJSONOutputProvenance)
+	t.Run("PEP440Options", testPEP440Options)
+	t.Run("MavenMode", testMavenMode)
+	t.Run("HelmMode", testHelmMode)
+	t.Run("Tag", testTag)
+	t.Run("Bump", testBump)
+	t.Run("Changes", testChanges)
 }
 
 func testMainBranchVersioning(t *testing.T) {
@@ -1029,6 +1047,1074 @@ func testMultipleTagsHighestVersion(t *testing.T) {
 	}
 }
 
+func testCalculateForRef(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	secondSHA := gitOutput(t, repo, "rev-parse", "HEAD")
+	makeCommit(t, repo, "third commit")
+	createTag(t, repo, "1.2.0")
+	makeCommit(t, repo, "fourth commit")
+
+	checkoutBranch(t, repo, "feature/login", true)
+	makeCommit(t, repo, "feature commit 1")
+	makeCommit(t, repo, "feature commit 2")
+	featureSHA := gitOutput(t, repo, "rev-parse", "HEAD")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	mode := "semver"
+	cfg := &config.Config{
+		MainBranch:  "main",
+		Mode:        &mode,
+		UseCIBranch: boolPtr(false),
+	}
+
+	tests := []struct {
+		name     string
+		ref      string
+		branch   string
+		expected string
+	}{
+		{name: "commit on main by SHA", ref: secondSHA, expected: "1.0.1"},
+		{name: "abbreviated SHA", ref: secondSHA[:8], expected: "1.0.1"},
+		{name: "tag", ref: "1.2.0", expected: "1.2.0"},
+		{name: "main branch", ref: "main", expected: "1.2.1"},
+		{name: "feature branch", ref: "feature/login", expected: "1.2.1-login.2"},
+		{name: "SHA with explicit branch", ref: featureSHA + "~1", branch: "feature/login", expected: "1.2.1-login.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := CalculateForRef(cfg, tt.ref, tt.branch)
+			if err != nil {
+				t.Fatalf("Failed to calculate version for %s: %v", tt.ref, err)
+			}
+			if version != tt.expected {
+				t.Errorf("Expected %s for ref %s, got %s", tt.expected, tt.ref, version)
+			}
+		})
+	}
+
+	// A commit that is not on main cannot be versioned without an explicit branch
+	if _, err := CalculateForRef(cfg, featureSHA, ""); err == nil {
+		t.Errorf("Expected error when versioning a feature commit without --branch")
+	}
+
+	// HEAD is untouched by calculating versions for other refs
+	version, err := CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if version != "1.2.1-login.2" {
+		t.Errorf("Expected 1.2.1-login.2 for HEAD, got %s", version)
+	}
+}
+
+func testHistory(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "2.0.0")
+	makeCommit(t, repo, "third commit")
+
+	checkoutBranch(t, repo, "feature/search", true)
+	makeCommit(t, repo, "feature commit 1")
+	makeCommit(t, repo, "feature commit 2")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	cfg := &config.Config{MainBranch: "main", UseCIBranch: boolPtr(false)}
+
+	entries, err := History(cfg, "main", 0)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	var versions []string
+	for _, entry := range entries {
+		versions = append(versions, entry.Version)
+	}
+	if strings.Join(versions, ",") != "2.0.1,2.0.0,1.0.0" {
+		t.Errorf("Expected main history 2.0.1,2.0.0,1.0.0, got %v", versions)
+	}
+	if entries[0].Subject != "third commit" {
+		t.Errorf("Expected subject 'third commit', got %q", entries[0].Subject)
+	}
+
+	// Feature branch history only contains the commits since it diverged from main
+	// and defaults to the current branch
+	entries, err = History(cfg, "", 0)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	versions = nil
+	for _, entry := range entries {
+		versions = append(versions, entry.Version)
+	}
+	if strings.Join(versions, ",") != "2.0.1-search.2,2.0.1-search.1" {
+		t.Errorf("Expected feature history 2.0.1-search.2,2.0.1-search.1, got %v", versions)
+	}
+
+	// Each entry matches a single calculation for the same commit
+	for _, entry := range entries {
+		mode := "semver"
+		single, err := CalculateForRef(&config.Config{MainBranch: "main", Mode: &mode}, entry.SHA, "feature/search")
+		if err != nil {
+			t.Fatalf("Failed to calculate version: %v", err)
+		}
+		if single != entry.Version {
+			t.Errorf("History version %s differs from calculated version %s for %s", entry.Version, single, entry.SHA)
+		}
+	}
+
+	if problems := AuditHistory(entries); len(problems) > 0 {
+		t.Errorf("Expected no problems in history, got %v", problems)
+	}
+
+	// Merged branches bring in commits and tags that aren't on the first-parent history, the
+	// single walk must see them like separate calculations do
+	checkoutBranch(t, repo, "main", false)
+	checkoutBranch(t, repo, "release/2.5", true)
+	makeCommit(t, repo, "release commit")
+	createTag(t, repo, "2.5.0")
+	makeCommit(t, repo, "release fix")
+	checkoutBranch(t, repo, "main", false)
+	makeCommit(t, repo, "main commit")
+	runGit(t, repo, "merge", "--no-ff", "-s", "ours", "-m", "Merge release/2.5", "release/2.5")
+	makeCommit(t, repo, "after merge")
+	makeCommit(t, repo, "after merge 2")
+
+	entries, err = History(cfg, "main", 0)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(entries) != 7 || entries[0].Version != "2.5.8" {
+		t.Errorf("Expected 7 commits with 2.5.8 at the tip, got %+v", entries)
+	}
+	for _, entry := range entries {
+		mode := "semver"
+		single, err := CalculateForRef(&config.Config{MainBranch: "main", Mode: &mode}, entry.SHA, "main")
+		if err != nil {
+			t.Fatalf("Failed to calculate version: %v", err)
+		}
+		if single != entry.Version {
+			t.Errorf("History version %s differs from calculated version %s for %s (%s)", entry.Version, single, entry.SHA, entry.Subject)
+		}
+	}
+}
+
+func testFind(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "1.4.0")
+	makeCommit(t, repo, "third commit")
+	thirdSHA := gitOutput(t, repo, "rev-parse", "HEAD")
+	makeCommit(t, repo, "fourth commit")
+
+	checkoutBranch(t, repo, "feature/login-fix", true)
+	makeCommit(t, repo, "feature commit 1")
+	makeCommit(t, repo, "feature commit 2")
+	featureSHA := gitOutput(t, repo, "rev-parse", "HEAD")
+	checkoutBranch(t, repo, "main", false)
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	versionPrefix := "v"
+	cfg := &config.Config{MainBranch: "main", VersionPrefix: &versionPrefix}
+
+	tests := []struct {
+		name    string
+		version string
+		sha     string
+		branch  string
+		baseTag string
+	}{
+		{name: "release on main", version: "1.4.1", sha: thirdSHA, branch: "main", baseTag: "1.4.0"},
+		{name: "with version prefix", version: "v1.4.1", sha: thirdSHA, branch: "main", baseTag: "1.4.0"},
+		{name: "feature branch prerelease", version: "1.4.1-login-fix.2", sha: featureSHA, branch: "feature/login-fix", baseTag: "1.4.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Find(cfg, tt.version)
+			if err != nil {
+				t.Fatalf("Failed to find version %s: %v", tt.version, err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("Expected exactly one result for %s, got %v", tt.version, results)
+			}
+			if results[0].SHA != tt.sha || results[0].Branch != tt.branch || results[0].BaseTag != tt.baseTag {
+				t.Errorf("Expected %s on %s based on %s, got %s on %s based on %s",
+					tt.sha, tt.branch, tt.baseTag, results[0].SHA, results[0].Branch, results[0].BaseTag)
+			}
+		})
+	}
+
+	results, err := Find(cfg, "9.9.9")
+	if err != nil {
+		t.Fatalf("Failed to search for version: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results for 9.9.9, got %v", results)
+	}
+
+	if _, err := Find(cfg, "not-a-version"); err == nil {
+		t.Errorf("Expected error for invalid version")
+	}
+}
+
+func testBranches(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "3.0.0")
+
+	checkoutBranch(t, repo, "feature/stale", true)
+	makeCommit(t, repo, "stale commit 1")
+	makeCommit(t, repo, "stale commit 2")
+	checkoutBranch(t, repo, "main", false)
+	makeCommit(t, repo, "main commit")
+	createTag(t, repo, "3.1.0")
+
+	checkoutBranch(t, repo, "feature/fresh", true)
+	makeCommit(t, repo, "fresh commit")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	// failOnOutdatedBase must not prevent the overview from being calculated
+	cfg := &config.Config{MainBranch: "main", FailOnOutdatedBase: boolPtr(true)}
+	statuses, err := Branches(cfg)
+	if err != nil {
+		t.Fatalf("Failed to get branches: %v", err)
+	}
+
+	byBranch := make(map[string]BranchStatus)
+	for _, s := range statuses {
+		byBranch[s.Ref] = s
+	}
+	if len(byBranch) != 3 {
+		t.Fatalf("Expected 3 branches, got %v", statuses)
+	}
+
+	if main := byBranch["main"]; !main.IsMainBranch || main.Version != "3.1.0" {
+		t.Errorf("Expected main at 3.1.0, got %+v", main)
+	}
+	fresh := byBranch["feature/fresh"]
+	if fresh.Version != "3.1.1-fresh.1" || fresh.Ahead != 1 || fresh.Behind != 0 || fresh.Outdated {
+		t.Errorf("Unexpected status for feature/fresh: %+v", fresh)
+	}
+	stale := byBranch["feature/stale"]
+	if stale.Version != "3.0.2-stale.2" || stale.Ahead != 2 || stale.Behind != 1 || !stale.Outdated || stale.NewTagOnMain != "3.1.0" {
+		t.Errorf("Unexpected status for feature/stale: %+v", stale)
+	}
+
+	// The drift is the one the version was calculated with, in every outdatedBaseCheckMode
+	checkoutBranch(t, repo, "main", false)
+	makeCommit(t, repo, "untagged main commit")
+	checkAll := "all"
+	cfg = &config.Config{MainBranch: "main", OutdatedBaseCheckMode: &checkAll}
+	statuses, err = Branches(cfg)
+	if err != nil {
+		t.Fatalf("Failed to get branches: %v", err)
+	}
+	for _, s := range statuses {
+		if s.Ref != "feature/fresh" {
+			continue
+		}
+		result, err := CalculateResult(cfg, "feature/fresh", "")
+		if err != nil {
+			t.Fatalf("Failed to calculate version: %v", err)
+		}
+		if s.Version != result.Semver || s.Behind != 1 || !s.Outdated || s.Outdated != result.Outdated || s.NewTagOnMain != "" {
+			t.Errorf("Expected feature/fresh outdated like %+v, got %+v", result, s)
+		}
+	}
+}
+
+func testConfigurableRemote(t *testing.T) {
+	upstream := setupTestRepo(t, "main")
+	defer cleanup(upstream)
+	makeCommit(t, upstream, "second commit")
+	createTag(t, upstream, "1.0.0")
+
+	// A fork-style clone where the shared repository is called "upstream"
+	clone, err := os.MkdirTemp("", "autoversion-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer cleanup(clone)
+	runGit(t, clone, "clone", "-o", "upstream", upstream, ".")
+	runGit(t, clone, "config", "user.email", "test@example.com")
+	runGit(t, clone, "config", "user.name", "Test User")
+	runGit(t, clone, "config", "commit.gpgsign", "false")
+	checkoutBranch(t, clone, "feature/remote", true)
+	makeCommit(t, clone, "feature commit")
+
+	// upstream/main moves on and gets a new tag, the local main stays behind
+	makeCommit(t, upstream, "upstream commit")
+	createTag(t, upstream, "1.1.0")
+	runGit(t, clone, "fetch", "upstream", "--tags")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(clone); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	mode := "semver"
+	local := defaults.MainBranchSourceLocal
+	remote := defaults.MainBranchSourceRemote
+
+	// The local main doesn't have the new tag, so the branch isn't outdated
+	cfg := &config.Config{MainBranch: "main", Mode: &mode, Remote: "upstream", MainBranchSource: &local, FailOnOutdatedBase: boolPtr(true)}
+	version, err := CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version with local main: %v", err)
+	}
+	if version != "1.0.1-remote.1" {
+		t.Errorf("Expected 1.0.1-remote.1, got %s", version)
+	}
+
+	// upstream/main has the new tag, so the branch is outdated
+	cfg.MainBranchSource = &remote
+	if _, err := CalculateWithConfig(cfg); err == nil {
+		t.Errorf("Expected outdated base error when comparing against upstream/main")
+	}
+
+	// Without a local main, the main branch is only found on the configured remote
+	runGit(t, clone, "branch", "-D", "main")
+	cfg = &config.Config{MainBranch: "main", Mode: &mode, Remotes: []string{"origin", "upstream"}}
+	version, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version with remote-only main: %v", err)
+	}
+	// The base version now counts the commit upstream/main has beyond the old local main
+	if version != "1.0.2-remote.1" {
+		t.Errorf("Expected 1.0.2-remote.1, got %s", version)
+	}
+
+	cfg = &config.Config{MainBranch: "main", Mode: &mode}
+	if _, err := CalculateWithConfig(cfg); err == nil {
+		t.Errorf("Expected error when main only exists on a remote that isn't configured")
+	}
+
+	invalid := "somewhere"
+	cfg = &config.Config{MainBranch: "main", Mode: &mode, MainBranchSource: &invalid}
+	if _, err := CalculateWithConfig(cfg); err == nil {
+		t.Errorf("Expected error for invalid mainBranchSource")
+	}
+}
+
+func testMainBranchDivergence(t *testing.T) {
+	remoteRepo := setupTestRepo(t, "main")
+	defer cleanup(remoteRepo)
+	makeCommit(t, remoteRepo, "second commit")
+
+	clone, err := os.MkdirTemp("", "autoversion-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer cleanup(clone)
+	runGit(t, clone, "clone", remoteRepo, ".")
+	runGit(t, clone, "config", "user.email", "test@example.com")
+	runGit(t, clone, "config", "user.name", "Test User")
+	runGit(t, clone, "config", "commit.gpgsign", "false")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(clone); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	jsonMode := "json"
+	cfg := &config.Config{MainBranch: "main", Mode: &jsonMode}
+
+	// Local and remote main match, so nothing is reported
+	output, err := CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	var result VersionOutput
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if result.MainBranchDivergence != nil {
+		t.Errorf("Expected no divergence, got %+v", result.MainBranchDivergence)
+	}
+
+	// The local main gets one commit of its own and origin/main gets two others
+	makeCommit(t, clone, "local commit")
+	makeCommit(t, remoteRepo, "remote commit 1")
+	makeCommit(t, remoteRepo, "remote commit 2")
+	runGit(t, clone, "fetch", "origin")
+
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	result = VersionOutput{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	expected := MainBranchDivergenceOutput{LocalRef: "main", RemoteRef: "origin/main", UsedRef: "main", Ahead: 1, Behind: 2}
+	if result.MainBranchDivergence == nil || *result.MainBranchDivergence != expected {
+		t.Errorf("Expected divergence %+v, got %+v", expected, result.MainBranchDivergence)
+	}
+
+	remote := defaults.MainBranchSourceRemote
+	cfg.MainBranchSource = &remote
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	result = VersionOutput{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if result.MainBranchDivergence == nil || result.MainBranchDivergence.UsedRef != "origin/main" {
+		t.Errorf("Expected origin/main to be used, got %+v", result.MainBranchDivergence)
+	}
+
+	cfg.FailOnMainBranchDivergence = boolPtr(true)
+	if _, err := CalculateWithConfig(cfg); err == nil {
+		t.Errorf("Expected error when failOnMainBranchDivergence is set and main has diverged")
+	}
+}
+
+func testOutputTemplate(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "v2.1.0")
+	makeCommit(t, repo, "third commit")
+	checkoutBranch(t, repo, "feature/Login-Page", true)
+	makeCommit(t, repo, "login commit 1")
+	makeCommit(t, repo, "login commit 2")
+	sha := gitOutput(t, repo, "rev-parse", "HEAD")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	tagPrefix := "v"
+	tmpl := "{{.Semver}}|{{.Branch}}|{{.SanitizedBranch}}|{{.Prerelease}}|{{.Build}}|{{.BaseTag}}|{{.CommitsSinceTag}}|{{.BranchCommits}}|{{.IsMainBranch}}|{{.SHA}}"
+	cfg := &config.Config{MainBranch: "main", TagPrefix: &tagPrefix, OutputTemplate: &tmpl}
+	output, err := CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	expected := "2.1.1-login-page.2|feature/Login-Page|login-page|login-page|2|v2.1.0|3|2|false|" + sha
+	if output != expected {
+		t.Errorf("Expected %s, got %s", expected, output)
+	}
+
+	// Tagged commits are still rendered with the branch they were built on
+	checkoutBranch(t, repo, "main", false)
+	runGit(t, repo, "tag", "-a", "v2.2.0", "-m", "Tag v2.2.0")
+	tmpl = "{{.Major}}.{{.Minor}}-{{.ShortSHA}} {{.Branch}} {{.IsRelease}} {{.BaseTag}}"
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	expected = "2.2-" + gitOutput(t, repo, "rev-parse", "--short=7", "HEAD") + " main true v2.2.0"
+	if output != expected {
+		t.Errorf("Expected %s, got %s", expected, output)
+	}
+
+	tmpl = "{{.Missing}}"
+	if _, err := CalculateWithConfig(cfg); err == nil {
+		t.Errorf("Expected error for a template that refers to an unknown field")
+	}
+}
+
+func testJSONOutputProvenance(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "1.3.0")
+	checkoutBranch(t, repo, "feature/report", true)
+	makeCommit(t, repo, "report commit")
+	checkoutBranch(t, repo, "main", false)
+	makeCommit(t, repo, "main commit")
+	createTag(t, repo, "1.4.0")
+	checkoutBranch(t, repo, "feature/report", false)
+	sha := gitOutput(t, repo, "rev-parse", "HEAD")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	jsonMode := "json"
+	cfg := &config.Config{MainBranch: "main", Mode: &jsonMode}
+	output, err := CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	var result VersionOutput
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+
+	expected := VersionOutput{
+		SchemaVersion:    OutputSchemaVersion,
+		Semver:           "1.3.2-report.1",
+		SemverWithPrefix: "1.3.2-report.1",
+		Pep440:           "1.3.2a1",
+		Pep440WithPrefix: "1.3.2a1",
+		Major:            1,
+		Minor:            3,
+		Patch:            2,
+		Prerelease:       "report",
+		Build:            1,
+		Branch:           "feature/report",
+		SanitizedBranch:  "report",
+		SHA:              sha,
+		BaseTag:          "1.3.0",
+		CommitsSinceTag:  1,
+		OutdatedBase:     true,
+	}
+	if result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	// CI branch detection is reported
+	os.Setenv("GITHUB_HEAD_REF", "feature/report")
+	defer os.Unsetenv("GITHUB_HEAD_REF")
+	cfg.UseCIBranch = boolPtr(true)
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	result = VersionOutput{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if !result.CIBranchUsed || result.Branch != "feature/report" {
+		t.Errorf("Expected the CI branch to be used, got %+v", result)
+	}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func testPEP440Options(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "1.2.0")
+	checkoutBranch(t, repo, "feature/feat-x", true)
+	makeCommit(t, repo, "feat-x commit 1")
+	makeCommit(t, repo, "feat-x commit 2")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	mode := defaults.ModePep440
+	segment := "dev"
+	cfg := &config.Config{MainBranch: "main", Mode: &mode, Pep440BranchSegment: &segment, Pep440LocalLabel: boolPtr(true)}
+	output, err := CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if output != "1.2.1.dev2+feat.x" {
+		t.Errorf("Expected 1.2.1.dev2+feat.x, got %s", output)
+	}
+
+	// Release candidate tags keep their segment
+	createTag(t, repo, "1.3.0-rc.1")
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if output != "1.3.0rc1" {
+		t.Errorf("Expected 1.3.0rc1, got %s", output)
+	}
+
+	segment = "x"
+	if _, err := CalculateWithConfig(cfg); err == nil {
+		t.Errorf("Expected error for an invalid pep440BranchSegment")
+	}
+}
+
+func testMavenMode(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "1.2.3")
+	makeCommit(t, repo, "third commit")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	mode := defaults.ModeMaven
+	behavior := "pre"
+	cfg := &config.Config{MainBranch: "main", Mode: &mode, MainBranchBehavior: &behavior}
+	output, err := CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if output != "1.2.4-SNAPSHOT" {
+		t.Errorf("Expected 1.2.4-SNAPSHOT, got %s", output)
+	}
+
+	// Feature branches sort after the release under Maven rules, which is only a warning
+	checkoutBranch(t, repo, "feature/feature-x", true)
+	makeCommit(t, repo, "feature commit")
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if output != "1.2.4-feature-x-SNAPSHOT" {
+		t.Errorf("Expected 1.2.4-feature-x-SNAPSHOT, got %s", output)
+	}
+
+	style := defaults.MavenSnapshotStyleTimestamp
+	cfg.MavenSnapshotStyle = &style
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	authorTime, err := strconv.ParseInt(gitOutput(t, repo, "log", "-1", "--format=%at"), 10, 64)
+	if err != nil {
+		t.Fatalf("Failed to parse commit time: %v", err)
+	}
+	timestamp := time.Unix(authorTime, 0).UTC().Format("20060102.150405")
+	if output != "1.2.4-feature-x-"+timestamp+"-1" {
+		t.Errorf("Expected 1.2.4-feature-x-%s-1, got %s", timestamp, output)
+	}
+
+	style = "nightly"
+	if _, err := CalculateWithConfig(cfg); err == nil {
+		t.Errorf("Expected error for an invalid mavenSnapshotStyle")
+	}
+}
+
+func testHelmMode(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "chart-1.0.3")
+	createTag(t, repo, "v2.4.0")
+	makeCommit(t, repo, "third commit")
+	createTag(t, repo, "v2.5.0")
+	makeCommit(t, repo, "fourth commit")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	mode := defaults.ModeHelm
+	chartPrefix := "chart-"
+	appPrefix := "v"
+	versionPrefix := "v"
+	cfg := &config.Config{
+		MainBranch:              "main",
+		Mode:                    &mode,
+		TagPrefix:               &appPrefix,
+		HelmChartTagPrefix:      &chartPrefix,
+		HelmAppVersionTagPrefix: &appPrefix,
+		VersionPrefix:           &versionPrefix,
+	}
+	output, err := CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	// The version prefix isn't added to JSON output
+	if output != `{"version":"1.0.5","appVersion":"2.5.1"}` {
+		t.Errorf("Expected chart version 1.0.5 and appVersion 2.5.1, got %s", output)
+	}
+
+	checkoutBranch(t, repo, "feature/chart-fix", true)
+	makeCommit(t, repo, "feature commit")
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if output != `{"version":"1.0.4-chart-fix.1","appVersion":"2.5.1-chart-fix.1"}` {
+		t.Errorf("Expected feature branch versions, got %s", output)
+	}
+
+	// A detached tagged commit that isn't on main has no branch, the chart version is a release
+	checkoutBranch(t, repo, "main", false)
+	checkoutBranch(t, repo, "release/3.0", true)
+	makeCommit(t, repo, "release commit")
+	createTag(t, repo, "v3.0.0")
+	runGit(t, repo, "checkout", "--detach")
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if output != `{"version":"1.0.6","appVersion":"3.0.0"}` {
+		t.Errorf("Expected chart version 1.0.6 and appVersion 3.0.0, got %s", output)
+	}
+
+	// A commit with both a chart and an app tag uses the tag with each prefix
+	runGit(t, repo, "checkout", "-b", "feature/both-tags", "chart-1.0.3")
+	output, err = CalculateWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if output != `{"version":"1.0.3","appVersion":"2.4.0"}` {
+		t.Errorf("Expected chart version 1.0.3 and appVersion 2.4.0, got %s", output)
+	}
+}
+
+func testTag(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+	remote, err := os.MkdirTemp("", "autoversion-remote-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer cleanup(remote)
+	runGit(t, remote, "init", "--bare")
+	runGit(t, repo, "remote", "add", "origin", remote)
+
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "v1.0.1")
+	makeCommit(t, repo, "third commit")
+	head := gitOutput(t, repo, "rev-parse", "HEAD")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	tagPrefix := "v"
+	cfg := &config.Config{MainBranch: "main", TagPrefix: &tagPrefix}
+
+	// A dry run checks everything but creates nothing
+	result, err := Tag(cfg, TagOptions{DryRun: true, Push: true})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if result.Name != "v1.0.2" || result.SHA != head || result.Remote != "origin" || result.Created {
+		t.Errorf("Unexpected dry run result: %+v", result)
+	}
+	if tags := gitOutput(t, repo, "tag", "--list"); tags != "v1.0.1" {
+		t.Errorf("Expected no tag to be created by a dry run, got %s", tags)
+	}
+
+	// A dirty worktree is refused
+	if err := os.WriteFile(filepath.Join(repo, "test.txt"), []byte("changed\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if _, err := Tag(cfg, TagOptions{}); err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Errorf("Expected an error for a dirty worktree, got %v", err)
+	}
+	runGit(t, repo, "checkout", "--", "test.txt")
+	// Untracked files don't make the worktree dirty
+	if err := os.WriteFile(filepath.Join(repo, "untracked.txt"), []byte("untracked\n"), 0644); err != nil {
+		t.Fatalf("Failed to write untracked file: %v", err)
+	}
+
+	result, err = Tag(cfg, TagOptions{Push: true})
+	if err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+	if !result.Created || result.Remote != "origin" {
+		t.Errorf("Expected the tag to be created and pushed, got %+v", result)
+	}
+	if tagType := gitOutput(t, repo, "cat-file", "-t", "v1.0.2"); tagType != "tag" {
+		t.Errorf("Expected an annotated tag, got %s", tagType)
+	}
+	if message := gitOutput(t, repo, "tag", "--list", "--format=%(contents:subject)", "v1.0.2"); message != "Release 1.0.2" {
+		t.Errorf("Expected the default tag message, got %q", message)
+	}
+	if target := gitOutput(t, repo, "rev-parse", "v1.0.2^{commit}"); target != head {
+		t.Errorf("Expected the tag on %s, got %s", head, target)
+	}
+	if remoteTags := gitOutput(t, remote, "tag", "--list"); !strings.Contains(remoteTags, "v1.0.2") {
+		t.Errorf("Expected the tag to be pushed, got %q", remoteTags)
+	}
+	calculated, err := CalculateResult(cfg, "", "")
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if calculated.Semver != "1.0.2" || calculated.BaseTag != "v1.0.2" {
+		t.Errorf("Expected the tagged version 1.0.2, got %s", calculated.Semver)
+	}
+
+	// The tag exists now
+	if _, err := Tag(cfg, TagOptions{}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an error for an existing tag, got %v", err)
+	}
+
+	// A branch other than main is refused unless forced
+	checkoutBranch(t, repo, "feature/x", true)
+	makeCommit(t, repo, "feature commit")
+	if _, err := Tag(cfg, TagOptions{}); err == nil || !strings.Contains(err.Error(), "not a main branch") {
+		t.Errorf("Expected an error for a feature branch, got %v", err)
+	}
+	result, err = Tag(cfg, TagOptions{Force: true, Message: "Preview"})
+	if err != nil {
+		t.Fatalf("Failed to force a tag on a feature branch: %v", err)
+	}
+	if result.Name != "v1.0.3-x.1" || result.Remote != "" {
+		t.Errorf("Unexpected forced tag: %+v", result)
+	}
+	if message := gitOutput(t, repo, "tag", "--list", "--format=%(contents:subject)", "v1.0.3-x.1"); message != "Preview" {
+		t.Errorf("Expected the given tag message, got %q", message)
+	}
+
+	// With mainBranchBehavior "pre", main gets the release version, not the "pre" build
+	checkoutBranch(t, repo, "main", false)
+	makeCommit(t, repo, "fourth commit")
+	makeCommit(t, repo, "fifth commit")
+	pre := "pre"
+	preCfg := &config.Config{MainBranch: "main", TagPrefix: &tagPrefix, MainBranchBehavior: &pre}
+	calculated, err = CalculateResult(preCfg, "", "")
+	if err != nil {
+		t.Fatalf("Failed to calculate version: %v", err)
+	}
+	if calculated.Semver != "1.0.4-pre.1" {
+		t.Fatalf("Expected prerelease 1.0.4-pre.1, got %s", calculated.Semver)
+	}
+	result, err = Tag(preCfg, TagOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if result.Name != "v1.0.4" || result.Version != "1.0.4" {
+		t.Errorf("Expected the release tag v1.0.4 for a pre build, got %+v", result)
+	}
+}
+
+func testBump(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	tagPrefix := "v"
+	cfg := &config.Config{MainBranch: "main", TagPrefix: &tagPrefix}
+
+	// Without tags the version is bumped from 0.0.0
+	result, err := Bump(cfg, "minor", "", nil)
+	if err != nil {
+		t.Fatalf("Bump failed: %v", err)
+	}
+	if result.Version != "0.1.0" || result.BaseTag != "" || result.Warning != "" {
+		t.Errorf("Expected 0.1.0 without a base tag, got %+v", result)
+	}
+
+	createTag(t, repo, "v1.2.0")
+	makeCommit(t, repo, "second commit")
+	createTag(t, repo, "v1.3.0-rc.1")
+	createTag(t, repo, "v1.3.0-rc.2")
+	makeCommit(t, repo, "third commit")
+
+	// The highest tag of the release is the base
+	result, err = Bump(cfg, "prerelease", "", nil)
+	if err != nil {
+		t.Fatalf("Bump failed: %v", err)
+	}
+	if result.Version != "1.3.0-rc.3" || result.BaseTag != "v1.3.0-rc.2" || result.Warning != "" {
+		t.Errorf("Expected 1.3.0-rc.3 from v1.3.0-rc.2, got %+v", result)
+	}
+
+	// A tag on another branch that is higher than the bumped version is warned about
+	checkoutBranch(t, repo, "release/2.0", true)
+	makeCommit(t, repo, "release commit")
+	createTag(t, repo, "v2.0.0")
+	checkoutBranch(t, repo, "main", false)
+	result, err = Bump(cfg, "minor", "", nil)
+	if err != nil {
+		t.Fatalf("Bump failed: %v", err)
+	}
+	if result.Version != "1.3.0" || !strings.Contains(result.Warning, "v2.0.0") {
+		t.Errorf("Expected 1.3.0 with a warning about v2.0.0, got %+v", result)
+	}
+
+	result, err = Bump(cfg, "minor", "", &TagOptions{})
+	if err != nil {
+		t.Fatalf("Bump with tag failed: %v", err)
+	}
+	if result.Tag == nil || !result.Tag.Created || result.Tag.Name != "v1.3.0" {
+		t.Errorf("Expected tag v1.3.0 to be created, got %+v", result.Tag)
+	}
+	if target := gitOutput(t, repo, "rev-parse", "v1.3.0^{commit}"); target != gitOutput(t, repo, "rev-parse", "HEAD") {
+		t.Errorf("Expected v1.3.0 on HEAD, got %s", target)
+	}
+
+	if _, err := Bump(cfg, "major", "", &TagOptions{}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an error for the existing tag v2.0.0, got %v", err)
+	}
+}
+
+func testChanges(t *testing.T) {
+	repo := setupTestRepo(t, "main")
+	defer cleanup(repo)
+
+	createTag(t, repo, "v1.0.0")
+	makeCommit(t, repo, "feat: first feature")
+	makeCommit(t, repo, "fix: first fix")
+	createTag(t, repo, "v1.1.0")
+	makeCommit(t, repo, "feat: second feature")
+	checkoutBranch(t, repo, "feature/x", true)
+	makeCommit(t, repo, "fix: branch fix")
+	checkoutBranch(t, repo, "main", false)
+	runGit(t, repo, "merge", "--no-ff", "-m", "Merge branch 'feature/x'", "feature/x")
+
+	oldDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("Failed to change to repo directory: %v", err)
+	}
+	defer os.Chdir(oldDir)
+
+	tagPrefix := "v"
+	cfg := &config.Config{MainBranch: "main", TagPrefix: &tagPrefix}
+
+	subjects := func(changes *ChangeSet) []string {
+		var result []string
+		for _, commit := range changes.Commits {
+			result = append(result, commit.Subject())
+		}
+		return result
+	}
+
+	// The upcoming release lists the commits since the most recent tag, without merge commits
+	changes, err := Changes(cfg, "", "")
+	if err != nil {
+		t.Fatalf("Changes failed: %v", err)
+	}
+	if changes.Version != "1.1.2" || changes.Tag != "" || changes.PreviousTag != "v1.1.0" || changes.PreviousVersion != "1.1.0" {
+		t.Errorf("Unexpected release: %+v", changes)
+	}
+	if got := strings.Join(subjects(changes), ", "); got != "feat: second feature, fix: branch fix" {
+		t.Errorf("Unexpected commits: %s", got)
+	}
+	files, err := changes.ChangedFiles(changes.Commits[0].Hash.String())
+	if err != nil {
+		t.Fatalf("ChangedFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "test.txt" {
+		t.Errorf("Expected test.txt to be changed, got %v", files)
+	}
+
+	// A tagged release lists the commits since the tag before
+	changes, err = Changes(cfg, "", "v1.1.0")
+	if err != nil {
+		t.Fatalf("Changes failed: %v", err)
+	}
+	if changes.Version != "1.1.0" || changes.Tag != "v1.1.0" || changes.PreviousTag != "v1.0.0" {
+		t.Errorf("Unexpected release: %+v", changes)
+	}
+	if got := strings.Join(subjects(changes), ", "); got != "fix: first fix, feat: first feature" {
+		t.Errorf("Unexpected commits: %s", got)
+	}
+
+	changes, err = Changes(cfg, "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatalf("Changes failed: %v", err)
+	}
+	if len(changes.Commits) != 2 || changes.PreviousVersion != "1.0.0" {
+		t.Errorf("Expected the 2 commits since v1.0.0, got %+v", changes)
+	}
+
+	// Without an earlier tag the whole history is listed
+	changes, err = Changes(cfg, "", "v1.0.0")
+	if err != nil {
+		t.Fatalf("Changes failed: %v", err)
+	}
+	if changes.PreviousTag != "" || len(changes.Commits) != 1 {
+		t.Errorf("Expected the initial commit only, got %+v", changes)
+	}
+}
@@ -0,0 +1,329 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+)
+
+// mavenTimestampFormat is the timestamp of unique snapshot versions (yyyyMMdd.HHmmss in UTC)
+const mavenTimestampFormat = "20060102.150405"
+
+// mavenSnapshotQualifier marks a Maven version as a snapshot
+const mavenSnapshotQualifier = "SNAPSHOT"
+
+// mavenSnapshotStyle returns the configured Maven snapshot style
+func mavenSnapshotStyle(cfg *config.Config) (string, error) {
+	style := defaults.DefaultMavenSnapshotStyle
+	if cfg.MavenSnapshotStyle != nil && *cfg.MavenSnapshotStyle != "" {
+		style = *cfg.MavenSnapshotStyle
+	}
+	for _, valid := range defaults.ValidMavenSnapshotStyles {
+		if style == valid {
+			return style, nil
+		}
+	}
+	return "", fmt.Errorf("invalid mavenSnapshotStyle '%s': must be one of %v", style, defaults.ValidMavenSnapshotStyles)
+}
+
+// ConvertToMaven converts a version result to a Maven version
+// Releases are MAJOR.MINOR.PATCH and tagged prereleases keep their tag (e.g. "1.3.0-rc.1"). Everything
+// else is a snapshot of the next release, with the branch name as a qualifier on feature branches:
+//   - "snapshot": "1.2.4-SNAPSHOT", "1.2.4-feature-x-SNAPSHOT"
+//   - "timestamp": "1.2.4-20260118.153012-3", "1.2.4-feature-x-20260118.153012-3", the unique snapshot
+//     version Maven deploys, from the commit time and the build number
+func ConvertToMaven(result *Result, style string) (string, error) {
+	core := fmt.Sprintf("%d.%d.%d", result.Major, result.Minor, result.Patch)
+	if result.IsRelease {
+		return core, nil
+	}
+	if result.isTagged() {
+		version, _, _ := strings.Cut(result.Semver, "+")
+		return version, nil
+	}
+
+	base := core
+	if result.Prerelease != defaults.PrereleaseID {
+		base += "-" + result.Prerelease
+	}
+
+	switch style {
+	case defaults.MavenSnapshotStyleSnapshot:
+		return base + "-" + mavenSnapshotQualifier, nil
+	case defaults.MavenSnapshotStyleTimestamp:
+		if result.CommitTime.IsZero() {
+			return "", fmt.Errorf("the commit time is needed for timestamped Maven snapshots")
+		}
+		return fmt.Sprintf("%s-%s-%d", base, result.CommitTime.UTC().Format(mavenTimestampFormat), result.Build), nil
+	default:
+		return "", fmt.Errorf("unsupported Maven snapshot style: %s", style)
+	}
+}
+
+// mavenBaseVersion returns the version Maven resolves a unique snapshot version by
+// ("1.2.4-feature-x-20260118.153012-3" -> "1.2.4-feature-x-SNAPSHOT"), other versions are unchanged
+func mavenBaseVersion(version string, result *Result) string {
+	if result.IsRelease || result.isTagged() || strings.HasSuffix(version, "-"+mavenSnapshotQualifier) {
+		return version
+	}
+	timestampStart := strings.LastIndex(version, "-")
+	if timestampStart != -1 {
+		timestampStart = strings.LastIndex(version[:timestampStart], "-")
+	}
+	if timestampStart == -1 {
+		return version
+	}
+	return version[:timestampStart] + "-" + mavenSnapshotQualifier
+}
+
+// validateMavenOrdering checks that a Maven version sorts the way the semver version does under
+// Maven's ComparableVersion rules: above the release it is based on and, for snapshots, below the
+// release it leads to. Going backwards is an error. Feature branch qualifiers that Maven sorts after
+// the release (it sorts unknown qualifiers after releases) are only a warning, because the branch
+// name is there by design.
+func validateMavenOrdering(version string, result *Result) error {
+	base := mavenBaseVersion(version, result)
+
+	if result.BaseVersion != "" && !result.isTagged() {
+		previous, _, _ := strings.Cut(result.BaseVersion, "+")
+		if CompareMaven(base, previous) <= 0 {
+			return fmt.Errorf("Maven version %s doesn't sort after %s (from tag %s)", base, previous, result.BaseTag)
+		}
+	}
+
+	if !result.IsRelease {
+		release := fmt.Sprintf("%d.%d.%d", result.Major, result.Minor, result.Patch)
+		if CompareMaven(base, release) >= 0 {
+			if result.IsMainBranch {
+				return fmt.Errorf("Maven version %s doesn't sort before the release %s", base, release)
+			}
+			log("WARNING: Maven sorts %s after the release %s because of the branch qualifier '%s'", base, release, result.Prerelease)
+		}
+	}
+	return nil
+}
+
+// CompareMaven compares two Maven versions according to the ComparableVersion rules of Maven 3
+// Returns -1 if a < b, 0 if a == b and 1 if a > b. See
+// https://maven.apache.org/pom.html#version-order-specification
+func CompareMaven(a, b string) int {
+	return sign(parseMaven(a).compare(parseMaven(b)))
+}
+
+// sign reduces a comparison result to -1, 0 or 1
+func sign(c int) int {
+	switch {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	}
+	return 0
+}
+
+// mavenItem is a parsed part of a Maven version: a number, a qualifier or a sublist
+// A nil mavenItem stands for a missing part, which compares like a release.
+type mavenItem interface {
+	compare(other mavenItem) int
+	isNull() bool
+}
+
+// mavenInt is a numeric part, stored without leading zeros so numbers of any size compare correctly
+type mavenInt string
+
+func (i mavenInt) isNull() bool { return i == "" }
+
+func (i mavenInt) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		if len(i) != len(o) {
+			return len(i) - len(o)
+		}
+		return strings.Compare(string(i), string(o))
+	default:
+		// Numbers sort after qualifiers and sublists
+		return 1
+	}
+}
+
+// mavenQualifiers are the well-known qualifiers in ascending order, "" is the release
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+// mavenReleaseIndex is the comparable form of the release qualifier
+var mavenReleaseIndex = strconv.Itoa(5)
+
+// mavenQualifierAliases are the spellings that mean the same as a well-known qualifier
+var mavenQualifierAliases = map[string]string{"ga": "", "final": "", "release": "", "cr": "rc"}
+
+// mavenString is a qualifier part
+type mavenString string
+
+func newMavenString(value string, followedByDigit bool) mavenString {
+	if followedByDigit && len(value) == 1 {
+		switch value {
+		case "a":
+			value = "alpha"
+		case "b":
+			value = "beta"
+		case "m":
+			value = "milestone"
+		}
+	}
+	if alias, ok := mavenQualifierAliases[value]; ok {
+		value = alias
+	}
+	return mavenString(value)
+}
+
+// comparable returns a string that sorts well-known qualifiers in their order, followed by all other
+// qualifiers in alphabetical order
+func (s mavenString) comparable() string {
+	for i, qualifier := range mavenQualifiers {
+		if string(s) == qualifier {
+			return strconv.Itoa(i)
+		}
+	}
+	return strconv.Itoa(len(mavenQualifiers)) + "-" + string(s)
+}
+
+func (s mavenString) isNull() bool { return s == "" }
+
+func (s mavenString) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		return strings.Compare(s.comparable(), mavenReleaseIndex)
+	case mavenString:
+		return strings.Compare(s.comparable(), o.comparable())
+	default:
+		// Qualifiers sort before numbers and sublists
+		return -1
+	}
+}
+
+// mavenList is a list of parts, started by a "-" or a switch between digits and letters
+type mavenList []mavenItem
+
+func (l *mavenList) isNull() bool { return len(*l) == 0 }
+
+func (l *mavenList) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(*l) == 0 {
+			return 0
+		}
+		return (*l)[0].compare(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case *mavenList:
+		for i := 0; i < len(*l) || i < len(*o); i++ {
+			var left, right mavenItem
+			if i < len(*l) {
+				left = (*l)[i]
+			}
+			if i < len(*o) {
+				right = (*o)[i]
+			}
+			var c int
+			if left == nil {
+				if right != nil {
+					c = -right.compare(left)
+				}
+			} else {
+				c = left.compare(right)
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	return 0
+}
+
+// normalize removes the trailing parts that compare like a release (0, "" and empty lists)
+func (l *mavenList) normalize() {
+	for i := len(*l) - 1; i >= 0; i-- {
+		item := (*l)[i]
+		if item.isNull() {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+		} else if _, isList := item.(*mavenList); !isList {
+			break
+		}
+	}
+}
+
+// parseMaven parses a version the way Maven's ComparableVersion does
+func parseMaven(version string) *mavenList {
+	version = strings.ToLower(version)
+
+	items := &mavenList{}
+	list := items
+	stack := []*mavenList{list}
+	startList := func() {
+		sublist := &mavenList{}
+		*list = append(*list, sublist)
+		list = sublist
+		stack = append(stack, list)
+	}
+	parseItem := func(isDigit bool, part string) mavenItem {
+		if isDigit {
+			return mavenInt(strings.TrimLeft(part, "0"))
+		}
+		return newMavenString(part, false)
+	}
+
+	isDigit := false
+	startIndex := 0
+	for i, c := range version {
+		switch {
+		case c == '.':
+			if i == startIndex {
+				*list = append(*list, mavenInt(""))
+			} else {
+				*list = append(*list, parseItem(isDigit, version[startIndex:i]))
+			}
+			startIndex = i + 1
+		case c == '-':
+			if i == startIndex {
+				*list = append(*list, mavenInt(""))
+			} else {
+				*list = append(*list, parseItem(isDigit, version[startIndex:i]))
+			}
+			startIndex = i + 1
+			startList()
+		case unicode.IsDigit(c):
+			if !isDigit && i > startIndex {
+				*list = append(*list, newMavenString(version[startIndex:i], true))
+				startIndex = i
+				startList()
+			}
+			isDigit = true
+		default:
+			if isDigit && i > startIndex {
+				*list = append(*list, parseItem(true, version[startIndex:i]))
+				startIndex = i
+				startList()
+			}
+			isDigit = false
+		}
+	}
+	if len(version) > startIndex {
+		*list = append(*list, parseItem(isDigit, version[startIndex:]))
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].normalize()
+	}
+	return items
+}
@@ -0,0 +1,120 @@
+package version
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompareMaven(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"1", "1.1", -1},
+		{"1.0", "1", 0},
+		{"1.ga", "1", 0},
+		{"1-final", "1.0.0", 0},
+		{"1-snapshot", "1", -1},
+		{"1-sp", "1", 1},
+		{"1-foo2", "1-foo10", -1},
+		{"1-a1", "1-alpha-1", 0},
+		{"1.0-alpha1", "1.0-beta1", -1},
+		{"1.0-beta1", "1.0-milestone1", -1},
+		{"1.0-milestone1", "1.0-rc1", -1},
+		{"1.0-RC1", "1.0-cr1", 0},
+		{"1.0-rc1", "1.0-SNAPSHOT", -1},
+		{"1.0-SNAPSHOT", "1.0", -1},
+		{"1.0", "1.0-sp1", -1},
+		{"1.2.4-SNAPSHOT", "1.2.3", 1},
+		{"1.2.4-SNAPSHOT", "1.2.4", -1},
+		{"1.3.0-rc.1", "1.3.0", -1},
+		{"1.2.4-feature-x-SNAPSHOT", "1.2.4", 1},
+		{"1.12345678901234567890", "1.9", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if c := CompareMaven(tt.a, tt.b); c != tt.expected {
+				t.Errorf("CompareMaven(%q, %q) = %d, want %d", tt.a, tt.b, c, tt.expected)
+			}
+			if c := CompareMaven(tt.b, tt.a); c != -tt.expected {
+				t.Errorf("CompareMaven(%q, %q) = %d, want %d", tt.b, tt.a, c, -tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertToMaven(t *testing.T) {
+	commitTime := time.Date(2026, 1, 18, 16, 30, 12, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name     string
+		result   Result
+		style    string
+		expected string
+	}{
+		{
+			name:     "release",
+			result:   Result{Semver: "1.2.3", Major: 1, Minor: 2, Patch: 3, IsRelease: true},
+			style:    "snapshot",
+			expected: "1.2.3",
+		},
+		{
+			name:     "main prerelease",
+			result:   Result{Semver: "1.2.4-pre.2", Major: 1, Minor: 2, Patch: 4, Prerelease: "pre", Build: 2, BaseTag: "1.2.3", CommitsSinceTag: 2},
+			style:    "snapshot",
+			expected: "1.2.4-SNAPSHOT",
+		},
+		{
+			name:     "feature branch",
+			result:   Result{Semver: "1.2.4-feature-x.3", Major: 1, Minor: 2, Patch: 4, Prerelease: "feature-x", Build: 3, BaseTag: "1.2.3", CommitsSinceTag: 3},
+			style:    "snapshot",
+			expected: "1.2.4-feature-x-SNAPSHOT",
+		},
+		{
+			name:     "main prerelease with timestamp",
+			result:   Result{Semver: "1.2.4-pre.2", Major: 1, Minor: 2, Patch: 4, Prerelease: "pre", Build: 2, CommitTime: commitTime},
+			style:    "timestamp",
+			expected: "1.2.4-20260118.153012-2",
+		},
+		{
+			name:     "feature branch with timestamp",
+			result:   Result{Semver: "1.2.4-feature-x.3", Major: 1, Minor: 2, Patch: 4, Prerelease: "feature-x", Build: 3, CommitTime: commitTime},
+			style:    "timestamp",
+			expected: "1.2.4-feature-x-20260118.153012-3",
+		},
+		{
+			name:     "tagged prerelease",
+			result:   Result{Semver: "1.3.0-rc.1", Major: 1, Minor: 3, Patch: 0, Prerelease: "rc", Build: 1, BaseTag: "v1.3.0-rc.1"},
+			style:    "snapshot",
+			expected: "1.3.0-rc.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := ConvertToMaven(&tt.result, tt.style)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, version)
+			}
+		})
+	}
+}
+
+func TestMavenBaseVersion(t *testing.T) {
+	result := &Result{Prerelease: "feature-x"}
+	tests := map[string]string{
+		"1.2.4-feature-x-20260118.153012-3": "1.2.4-feature-x-SNAPSHOT",
+		"1.2.4-20260118.153012-2":           "1.2.4-SNAPSHOT",
+		"1.2.4-SNAPSHOT":                    "1.2.4-SNAPSHOT",
+	}
+	for version, expected := range tests {
+		if base := mavenBaseVersion(version, result); base != expected {
+			t.Errorf("mavenBaseVersion(%q) = %q, want %q", version, base, expected)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+)
+
+// npmMaxSafeInteger is the largest number node-semver accepts in a version (Number.MAX_SAFE_INTEGER)
+const npmMaxSafeInteger = 1<<53 - 1
+
+// npmRangeLikeTag matches dist-tags that npm rejects because they parse as a semver range
+// ("1", "v2", "x"). Sanitized branch names only contain lowercase letters, digits and hyphens.
+var npmRangeLikeTag = regexp.MustCompile(`^v?[0-9]|^x$`)
+
+// NpmVersion is the version of an npm package and the dist-tag to publish it with
+type NpmVersion struct {
+	Version string `json:"version"`
+	DistTag string `json:"distTag"`
+}
+
+// NewNpmVersion returns the npm version and dist-tag for a version result
+// Releases are published as "latest". Branch prereleases use the sanitized branch name (or "next"
+// with npmPrereleaseTag: next), other prereleases use "next", so a prerelease never becomes
+// "latest". Build metadata is dropped because npm strips it when publishing.
+func NewNpmVersion(result *Result, cfg *config.Config) (NpmVersion, error) {
+	prereleaseTag := defaults.DefaultNpmPrereleaseTag
+	if cfg.NpmPrereleaseTag != nil && *cfg.NpmPrereleaseTag != "" {
+		prereleaseTag = *cfg.NpmPrereleaseTag
+	}
+	if prereleaseTag != defaults.NpmPrereleaseTagBranch && prereleaseTag != defaults.NpmPrereleaseTagNext {
+		return NpmVersion{}, fmt.Errorf("invalid npmPrereleaseTag '%s': must be one of %v", prereleaseTag, defaults.ValidNpmPrereleaseTags)
+	}
+
+	version, _, _ := strings.Cut(result.Semver, "+")
+	if err := validateNpmVersion(version); err != nil {
+		return NpmVersion{}, err
+	}
+
+	distTag := defaults.NpmDistTagNext
+	switch {
+	case result.IsRelease:
+		distTag = defaults.NpmDistTagLatest
+	case prereleaseTag == defaults.NpmPrereleaseTagBranch && !result.IsMainBranch && !result.isTagged():
+		if tag := result.SanitizedBranch; isValidNpmDistTag(tag) {
+			distTag = tag
+		} else {
+			log("Branch name '%s' can't be used as npm dist-tag, using '%s'", tag, distTag)
+		}
+	}
+	return NpmVersion{Version: version, DistTag: distTag}, nil
+}
+
+// validateNpmVersion checks a version against the rules npm and node-semver apply when publishing:
+// strict semver, at most 256 characters and no numbers larger than Number.MAX_SAFE_INTEGER
+func validateNpmVersion(version string) error {
+	if !IsValidSemver(version) {
+		return fmt.Errorf("%s is not a valid npm version", version)
+	}
+	if len(version) > defaults.NpmMaxVersionLength {
+		return fmt.Errorf("npm version %s is %d characters long, the limit is %d", version, len(version), defaults.NpmMaxVersionLength)
+	}
+	// Numeric identifiers of the core version and the prerelease; the prerelease starts at the first "-"
+	core, prerelease, _ := strings.Cut(version, "-")
+	identifiers := strings.Split(core, ".")
+	if prerelease != "" {
+		identifiers = append(identifiers, strings.Split(prerelease, ".")...)
+	}
+	for _, identifier := range identifiers {
+		if strings.Trim(identifier, "0123456789") != "" {
+			continue
+		}
+		if n, err := strconv.ParseUint(identifier, 10, 64); err != nil || n > npmMaxSafeInteger {
+			return fmt.Errorf("npm version %s contains the number %s, which is larger than %d", version, identifier, uint64(npmMaxSafeInteger))
+		}
+	}
+	return nil
+}
+
+// isValidNpmDistTag reports whether npm accepts a branch name as dist-tag for prereleases
+func isValidNpmDistTag(tag string) bool {
+	return tag != "" && tag != defaults.NpmDistTagLatest && tag != defaults.UnknownBranchName && !npmRangeLikeTag.MatchString(tag)
+}
@@ -0,0 +1,107 @@
+package version
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+)
+
+func TestNewNpmVersion(t *testing.T) {
+	next := "next"
+
+	tests := []struct {
+		name     string
+		result   Result
+		cfg      config.Config
+		expected NpmVersion
+	}{
+		{
+			name:     "release",
+			result:   Result{Semver: "1.2.3", IsRelease: true, IsMainBranch: true, SanitizedBranch: "main"},
+			expected: NpmVersion{Version: "1.2.3", DistTag: "latest"},
+		},
+		{
+			name:     "feature branch",
+			result:   Result{Semver: "1.2.4-login-x.3", Prerelease: "login-x", SanitizedBranch: "login-x"},
+			expected: NpmVersion{Version: "1.2.4-login-x.3", DistTag: "login-x"},
+		},
+		{
+			name:     "feature branch with next",
+			result:   Result{Semver: "1.2.4-login-x.3", Prerelease: "login-x", SanitizedBranch: "login-x"},
+			cfg:      config.Config{NpmPrereleaseTag: &next},
+			expected: NpmVersion{Version: "1.2.4-login-x.3", DistTag: "next"},
+		},
+		{
+			name:     "main branch prerelease",
+			result:   Result{Semver: "1.2.4-pre.3", Prerelease: "pre", IsMainBranch: true, SanitizedBranch: "main"},
+			expected: NpmVersion{Version: "1.2.4-pre.3", DistTag: "next"},
+		},
+		{
+			name:     "tagged prerelease",
+			result:   Result{Semver: "1.2.4-rc.1", Prerelease: "rc", BaseTag: "1.2.4-rc.1", SanitizedBranch: "release-1-2"},
+			expected: NpmVersion{Version: "1.2.4-rc.1", DistTag: "next"},
+		},
+		{
+			name:     "branch that looks like a range",
+			result:   Result{Semver: "1.2.4-1-x.3", Prerelease: "1-x", SanitizedBranch: "1-x"},
+			expected: NpmVersion{Version: "1.2.4-1-x.3", DistTag: "next"},
+		},
+		{
+			name:     "branch named latest",
+			result:   Result{Semver: "1.2.4-latest.3", Prerelease: "latest", SanitizedBranch: "latest"},
+			expected: NpmVersion{Version: "1.2.4-latest.3", DistTag: "next"},
+		},
+		{
+			name:     "build metadata",
+			result:   Result{Semver: "1.2.3+build.5", IsRelease: true},
+			expected: NpmVersion{Version: "1.2.3", DistTag: "latest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := NewNpmVersion(&tt.result, &tt.cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, version)
+			}
+		})
+	}
+
+	invalid := "beta"
+	if _, err := NewNpmVersion(&Result{Semver: "1.0.0"}, &config.Config{NpmPrereleaseTag: &invalid}); err == nil {
+		t.Errorf("Expected error for an invalid npmPrereleaseTag")
+	}
+}
+
+func TestValidateNpmVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		valid   bool
+	}{
+		{"1.2.3", true},
+		{"1.2.4-login-x.3", true},
+		{"1.2.4-99999999999999999999", false},
+		{"9007199254740991.0.0", true},
+		{"9007199254740992.0.0", false},
+		{"1.2.4-a99999999999999999999", true},
+		{"1.2.4-" + strings.Repeat("a", 250), true},
+		{"1.2.4-" + strings.Repeat("a", 251), false},
+		{"01.2.3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			err := validateNpmVersion(tt.version)
+			if tt.valid && err != nil {
+				t.Errorf("Expected %s to be valid, got %v", tt.version, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Expected %s to be invalid", tt.version)
+			}
+		})
+	}
+}
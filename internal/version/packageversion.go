@@ -0,0 +1,279 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
+)
+
+// packageRevisionRegex matches revisions that are valid as a Debian revision and an RPM release
+var packageRevisionRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+~]*$`)
+
+// ConvertToPackageVersion converts a semver version to a Debian or RPM package version
+// The prerelease is added with "~", which dpkg and rpm sort before the version without it
+// ("1.2.3-feature-x.4" -> "1.2.3~feature.x.4"). Hyphens become dots because they separate the
+// revision, and build metadata is dropped because it doesn't take part in semver ordering. The
+// configured packageEpoch and packageRevision give "EPOCH:VERSION-REVISION".
+func ConvertToPackageVersion(semver string, cfg *config.Config) (string, error) {
+	version := packageUpstreamVersion(semver)
+
+	if cfg.PackageEpoch != nil {
+		if *cfg.PackageEpoch < 0 {
+			return "", fmt.Errorf("invalid packageEpoch %d: must not be negative", *cfg.PackageEpoch)
+		}
+		if *cfg.PackageEpoch > 0 {
+			version = strconv.Itoa(*cfg.PackageEpoch) + ":" + version
+		}
+	}
+	if cfg.PackageRevision != nil && *cfg.PackageRevision != "" {
+		if !packageRevisionRegex.MatchString(*cfg.PackageRevision) {
+			return "", fmt.Errorf("invalid packageRevision '%s': must start with a letter or digit and contain only letters, digits and '.', '+' or '~'", *cfg.PackageRevision)
+		}
+		version += "-" + *cfg.PackageRevision
+	}
+	return version, nil
+}
+
+// packageUpstreamVersion converts a semver version to the version part of a package version
+func packageUpstreamVersion(semver string) string {
+	semver, _, _ = strings.Cut(semver, "+")
+	core, prerelease, found := strings.Cut(semver, "-")
+	if !found {
+		return core
+	}
+	return core + "~" + strings.ReplaceAll(prerelease, "-", ".")
+}
+
+// validatePackageOrdering checks that dpkg or rpm orders a version like semver does, compared to
+// the release it is based on and the release it leads to
+func validatePackageOrdering(result *Result, mode string) error {
+	compare := CompareDebian
+	if mode == defaults.ModeRpm {
+		compare = CompareRPM
+	}
+
+	references := []string{fmt.Sprintf("%d.%d.%d", result.Major, result.Minor, result.Patch)}
+	if result.BaseVersion != "" && IsValidSemver(result.BaseVersion) {
+		references = append(references, result.BaseVersion)
+	}
+	for _, reference := range references {
+		expected, err := CompareSemver(result.Semver, reference)
+		if err != nil {
+			return err
+		}
+		version, referenceVersion := packageUpstreamVersion(result.Semver), packageUpstreamVersion(reference)
+		if got := compare(version, referenceVersion); got != expected {
+			return fmt.Errorf("%s orders %s and %s differently than semver orders %s and %s", mode, version, referenceVersion, result.Semver, reference)
+		}
+	}
+	return nil
+}
+
+// splitEVR splits a package version into epoch, version and revision ("1:2.0-3" -> 1, "2.0", "3")
+func splitEVR(evr string) (int, string, string) {
+	epoch := 0
+	if before, after, found := strings.Cut(evr, ":"); found {
+		if n, err := strconv.Atoi(before); err == nil {
+			epoch = n
+			evr = after
+		}
+	}
+	version, revision := evr, ""
+	if lastHyphen := strings.LastIndex(evr, "-"); lastHyphen != -1 {
+		version, revision = evr[:lastHyphen], evr[lastHyphen+1:]
+	}
+	return epoch, version, revision
+}
+
+// CompareDebian compares two Debian package versions ([epoch:]upstream[-revision]) the way dpkg does
+// Returns -1 if a < b, 0 if a == b and 1 if a > b.
+func CompareDebian(a, b string) int {
+	aEpoch, aVersion, aRevision := splitEVR(a)
+	bEpoch, bVersion, bRevision := splitEVR(b)
+	if aEpoch != bEpoch {
+		return sign(aEpoch - bEpoch)
+	}
+	if c := debianCompareParts(aVersion, bVersion); c != 0 {
+		return c
+	}
+	return debianCompareParts(aRevision, bRevision)
+}
+
+// debianOrder is the sort weight of a character in the non-digit parts of a Debian version:
+// "~" sorts before everything including the end of the string, and letters before other characters
+func debianOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	c := s[i]
+	switch {
+	case c >= '0' && c <= '9':
+		return 0
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+// debianCompareParts compares upstream versions or revisions, alternating between non-digit parts
+// that compare character by character and digit parts that compare as numbers
+func debianCompareParts(a, b string) int {
+	isDigit := func(s string, i int) bool { return i < len(s) && s[i] >= '0' && s[i] <= '9' }
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a, i)) || (j < len(b) && !isDigit(b, j)) {
+			if ac, bc := debianOrder(a, i), debianOrder(b, j); ac != bc {
+				return sign(ac - bc)
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		firstDiff := 0
+		for isDigit(a, i) && isDigit(b, j) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if isDigit(a, i) {
+			return 1
+		}
+		if isDigit(b, j) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return sign(firstDiff)
+		}
+	}
+	return 0
+}
+
+// CompareRPM compares two RPM versions ([epoch:]version[-release]) the way rpm does
+// Returns -1 if a < b, 0 if a == b and 1 if a > b.
+func CompareRPM(a, b string) int {
+	aEpoch, aVersion, aRelease := splitEVR(a)
+	bEpoch, bVersion, bRelease := splitEVR(b)
+	if aEpoch != bEpoch {
+		return sign(aEpoch - bEpoch)
+	}
+	if c := rpmCompareParts(aVersion, bVersion); c != 0 {
+		return c
+	}
+	return rpmCompareParts(aRelease, bRelease)
+}
+
+// rpmCompareParts is rpmvercmp: versions are split into runs of digits and letters, everything else
+// separates them. "~" sorts before everything including the end of the string, "^" after the end
+// of the string but before anything else.
+func rpmCompareParts(a, b string) int {
+	if a == b {
+		return 0
+	}
+	isAlpha := func(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	at := func(s string, i int) byte {
+		if i < len(s) {
+			return s[i]
+		}
+		return 0
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isAlpha(a[i]) && !isDigit(a[i]) && a[i] != '~' && a[i] != '^' {
+			i++
+		}
+		for j < len(b) && !isAlpha(b[j]) && !isDigit(b[j]) && b[j] != '~' && b[j] != '^' {
+			j++
+		}
+
+		if at(a, i) == '~' || at(b, j) == '~' {
+			if at(a, i) != '~' {
+				return 1
+			}
+			if at(b, j) != '~' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+		if at(a, i) == '^' || at(b, j) == '^' {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if a[i] != '^' {
+				return 1
+			}
+			if b[j] != '^' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		segmentEnd := isAlpha
+		isNumber := isDigit(a[i])
+		if isNumber {
+			segmentEnd = isDigit
+		}
+		aEnd, bEnd := i, j
+		for aEnd < len(a) && segmentEnd(a[aEnd]) {
+			aEnd++
+		}
+		for bEnd < len(b) && segmentEnd(b[bEnd]) {
+			bEnd++
+		}
+		// Numeric segments sort after alphabetic ones
+		if bEnd == j {
+			if isNumber {
+				return 1
+			}
+			return -1
+		}
+
+		aSegment, bSegment := a[i:aEnd], b[j:bEnd]
+		if isNumber {
+			aSegment, bSegment = strings.TrimLeft(aSegment, "0"), strings.TrimLeft(bSegment, "0")
+			if len(aSegment) != len(bSegment) {
+				return sign(len(aSegment) - len(bSegment))
+			}
+		}
+		if c := strings.Compare(aSegment, bSegment); c != 0 {
+			return c
+		}
+		i, j = aEnd, bEnd
+	}
+
+	switch {
+	case i >= len(a) && j >= len(b):
+		return 0
+	case i < len(a):
+		return 1
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,126 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+)
+
+func TestConvertToPackageVersion(t *testing.T) {
+	epoch := 2
+	revision := "1"
+
+	tests := []struct {
+		name     string
+		semver   string
+		cfg      config.Config
+		expected string
+	}{
+		{"release", "1.2.3", config.Config{}, "1.2.3"},
+		{"prerelease", "1.2.3-feature.4", config.Config{}, "1.2.3~feature.4"},
+		{"hyphens in prerelease", "1.2.3-feature-x.4", config.Config{}, "1.2.3~feature.x.4"},
+		{"build metadata dropped", "1.2.3+build.5", config.Config{}, "1.2.3"},
+		{"epoch and revision", "1.2.3-rc.1", config.Config{PackageEpoch: &epoch, PackageRevision: &revision}, "2:1.2.3~rc.1-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := ConvertToPackageVersion(tt.semver, &tt.cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, version)
+			}
+		})
+	}
+
+	negative := -1
+	if _, err := ConvertToPackageVersion("1.2.3", &config.Config{PackageEpoch: &negative}); err == nil {
+		t.Errorf("Expected error for a negative epoch")
+	}
+	invalid := "1-2"
+	if _, err := ConvertToPackageVersion("1.2.3", &config.Config{PackageRevision: &invalid}); err == nil {
+		t.Errorf("Expected error for a revision with a hyphen")
+	}
+}
+
+func TestCompareDebian(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~~", "1.0~", -1},
+		{"1.0", "1.0+b1", -1},
+		{"1.0", "1.0a", -1},
+		{"2.9", "2.10", -1},
+		{"1.010", "1.10", 0},
+		{"1.0-1", "1.0-2", -1},
+		{"1:0.1", "2.0", 1},
+		{"1.2.3~feature.4", "1.2.3", -1},
+		{"1.2.3~feature.4", "1.2.2", 1},
+		{"1.2.3~rc.2", "1.2.3~rc.10", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if c := CompareDebian(tt.a, tt.b); c != tt.expected {
+				t.Errorf("CompareDebian(%q, %q) = %d, want %d", tt.a, tt.b, c, tt.expected)
+			}
+			if c := CompareDebian(tt.b, tt.a); c != -tt.expected {
+				t.Errorf("CompareDebian(%q, %q) = %d, want %d", tt.b, tt.a, c, -tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareRPM(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0^git1", "1.0", 1},
+		{"1.0^git1", "1.0.1", -1},
+		{"1.0", "1.0a", -1},
+		{"1.0a", "1.0.1", -1},
+		{"2.9", "2.10", -1},
+		{"1.010", "1.10", 0},
+		{"1.0_1", "1.0.1", 0},
+		{"1.0-1", "1.0-2", -1},
+		{"1:0.1", "2.0", 1},
+		{"1.2.3~feature.4", "1.2.3", -1},
+		{"1.2.3~rc.2", "1.2.3~rc.10", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if c := CompareRPM(tt.a, tt.b); c != tt.expected {
+				t.Errorf("CompareRPM(%q, %q) = %d, want %d", tt.a, tt.b, c, tt.expected)
+			}
+			if c := CompareRPM(tt.b, tt.a); c != -tt.expected {
+				t.Errorf("CompareRPM(%q, %q) = %d, want %d", tt.b, tt.a, c, -tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePackageOrdering(t *testing.T) {
+	result := &Result{Semver: "1.2.4-feature-x.3", Major: 1, Minor: 2, Patch: 4, BaseVersion: "1.2.3"}
+	for _, mode := range []string{"deb", "rpm"} {
+		if err := validatePackageOrdering(result, mode); err != nil {
+			t.Errorf("Unexpected %s ordering error: %v", mode, err)
+		}
+	}
+
+	// Semver sorts "a1" after "a", dpkg sorts the digit before the "."
+	result = &Result{Semver: "1.2.3-a1", Major: 1, Minor: 2, Patch: 3, BaseVersion: "1.2.3-a.2"}
+	if err := validatePackageOrdering(result, "deb"); err == nil {
+		t.Errorf("Expected an ordering error for %s after %s", result.Semver, result.BaseVersion)
+	}
+}
@@ -3,51 +3,337 @@ package version
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/defaults"
 )
 
-// ConvertToPEP440 converts a semver version string to PEP 440 format
+// PEP440Options control how semver prerelease labels are converted to PEP 440
+type PEP440Options struct {
+	BranchSegment string            // segment for branch labels: "a", "b", "rc" or "dev"
+	Labels        map[string]string // segment for known prerelease labels (e.g. "rc" -> "rc")
+	LocalLabel    bool              // add the branch name as a local version label (e.g. "+feat.x")
+}
+
+// DefaultPEP440Options returns the options ConvertToPEP440 uses: branches and "pre" become alpha
+// releases, without local labels
+func DefaultPEP440Options() PEP440Options {
+	return PEP440Options{
+		BranchSegment: defaults.DefaultPep440BranchSegment,
+		Labels:        defaults.Pep440Labels,
+	}
+}
+
+// pep440Options returns the PEP 440 conversion options for the configuration
+func pep440Options(cfg *config.Config) (PEP440Options, error) {
+	opts := DefaultPEP440Options()
+	if cfg.Pep440BranchSegment != nil && *cfg.Pep440BranchSegment != "" {
+		opts.BranchSegment = *cfg.Pep440BranchSegment
+	}
+	if !isValidPep440Segment(opts.BranchSegment) {
+		return opts, fmt.Errorf("invalid pep440BranchSegment '%s': must be one of %v", opts.BranchSegment, defaults.ValidPep440Segments)
+	}
+
+	if len(cfg.Pep440Labels) > 0 {
+		labels := make(map[string]string, len(defaults.Pep440Labels)+len(cfg.Pep440Labels))
+		for label, segment := range defaults.Pep440Labels {
+			labels[label] = segment
+		}
+		for label, segment := range cfg.Pep440Labels {
+			if !isValidPep440Segment(segment) {
+				return opts, fmt.Errorf("invalid pep440Labels segment '%s' for label '%s': must be one of %v", segment, label, defaults.ValidPep440Segments)
+			}
+			labels[strings.ToLower(label)] = segment
+		}
+		opts.Labels = labels
+	}
+
+	if cfg.Pep440LocalLabel != nil {
+		opts.LocalLabel = *cfg.Pep440LocalLabel
+	}
+	return opts, nil
+}
+
+// isValidPep440Segment checks if a segment is one of defaults.ValidPep440Segments
+func isValidPep440Segment(segment string) bool {
+	for _, valid := range defaults.ValidPep440Segments {
+		if segment == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertToPEP440 converts a semver version string to PEP 440 format with the default options
 // Examples:
 //   - "1.0.2-setup-build.1" -> "1.0.2a1"
 //   - "1.0.0-pre.5" -> "1.0.0a5"
-//   - "2.3.4-feature-auth.10" -> "2.3.4a10"
+//   - "2.0.0-rc.2" -> "2.0.0rc2"
 //   - "1.0.0" -> "1.0.0" (no change for release versions)
 func ConvertToPEP440(semver string) (string, error) {
-	// Release versions (no prerelease) remain unchanged
-	if !strings.Contains(semver, "-") {
-		return semver, nil
-	}
+	return ConvertToPEP440WithOptions(semver, DefaultPEP440Options())
+}
+
+// ConvertToPEP440WithOptions converts a semver version string to PEP 440 format
+// The prerelease label picks the segment: known labels (opts.Labels) map to their own segment, any
+// other label is a branch name and uses opts.BranchSegment. The build number becomes the segment
+// number. Branch names can be kept as a local version label, which PyPI doesn't accept but keeps
+// builds of different branches apart. Examples with BranchSegment "dev" and LocalLabel:
+//   - "1.2.0-feat-x.3" -> "1.2.0.dev3+feat.x"
+//   - "1.2.0-rc.1" -> "1.2.0rc1"
+func ConvertToPEP440WithOptions(semver string, opts PEP440Options) (string, error) {
+	// Build metadata has no PEP 440 equivalent
+	semver, _, _ = strings.Cut(semver, "+")
 
-	// Parse the semver: MAJOR.MINOR.PATCH-PRERELEASE.BUILD
-	// The prerelease part can contain hyphens (e.g., "setup-build.1" or "feature-auth.10")
-	dashIndex := strings.Index(semver, "-")
-	if dashIndex == -1 {
+	// Release versions (no prerelease) remain unchanged
+	corePart, prereleasePart, found := strings.Cut(semver, "-")
+	if !found {
 		return semver, nil
 	}
 
-	corePart := semver[:dashIndex]         // e.g., "1.0.2"
-	prereleasePart := semver[dashIndex+1:] // e.g., "setup-build.1" or "feature-auth.10"
-
-	// Extract the build number from prerelease (everything after the last dot)
-	// e.g., "setup-build.1" -> build = "1", identifier = "setup-build"
+	// The build number is everything after the last dot
+	// e.g., "setup-build.1" -> build = "1", label = "setup-build"
 	lastDotIndex := strings.LastIndex(prereleasePart, ".")
 	if lastDotIndex == -1 {
 		return "", fmt.Errorf("invalid prerelease format (missing build number): %s", semver)
 	}
+	label := prereleasePart[:lastDotIndex]
+	build, err := strconv.Atoi(prereleasePart[lastDotIndex+1:])
+	if err != nil || build < 0 {
+		return "", fmt.Errorf("invalid prerelease format (build number is not a number): %s", semver)
+	}
+
+	segment, known := opts.Labels[strings.ToLower(label)]
+	if !known {
+		segment = opts.BranchSegment
+	}
+
+	var b strings.Builder
+	b.WriteString(corePart)
+	if segment == defaults.Pep440SegmentDev {
+		b.WriteString(".")
+	}
+	b.WriteString(segment)
+	b.WriteString(strconv.Itoa(build))
+	if !known && opts.LocalLabel {
+		b.WriteString("+")
+		b.WriteString(strings.ToLower(strings.ReplaceAll(label, "-", ".")))
+	}
+	return b.String(), nil
+}
+
+// PEP440Version is a parsed PEP 440 version
+type PEP440Version struct {
+	Epoch   int
+	Release []int  // release segment, e.g. [1 2 0]
+	PreKind string // "a", "b" or "rc", empty when not a prerelease
+	Pre     int
+	Post    *int   // post-release number, nil when not a post-release
+	Dev     *int   // development release number, nil when not a development release
+	Local   string // local version label in normalized form, e.g. "feat.x"
+}
 
-	buildNumber := prereleasePart[lastDotIndex+1:]
-	// PEP 440 uses 'a' for alpha versions (similar to prerelease)
-	// Format: MAJOR.MINOR.PATCHaN where N is the build number
-	pep440Version := fmt.Sprintf("%sa%s", corePart, buildNumber)
+// pep440Regex is the version pattern from the PEP 440 appendix, which accepts every spelling
+// PEP 440 allows, e.g. "v1.0-RC.1", "1.0.post-2" or "1!2.0+ubuntu_1"
+var pep440Regex = regexp.MustCompile(`(?i)^v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(?P<pre_l>alpha|a|beta|b|preview|pre|c|rc)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+	`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
 
-	return pep440Version, nil
+// pep440PreKinds are the normalized spellings of the prerelease kinds
+var pep440PreKinds = map[string]string{
+	"a": "a", "alpha": "a",
+	"b": "b", "beta": "b",
+	"rc": "rc", "c": "rc", "pre": "rc", "preview": "rc",
 }
 
-// IsValidPEP440 checks if a string is a valid PEP 440 version
-// This is a simplified check for the versions we generate
+// ParsePEP440 parses any valid PEP 440 version
+func ParsePEP440(version string) (*PEP440Version, error) {
+	match := pep440Regex.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return nil, fmt.Errorf("invalid PEP 440 version: %s", version)
+	}
+	group := func(name string) string {
+		return match[pep440Regex.SubexpIndex(name)]
+	}
+
+	v := &PEP440Version{}
+	var err error
+	if epoch := group("epoch"); epoch != "" {
+		if v.Epoch, err = strconv.Atoi(epoch); err != nil {
+			return nil, fmt.Errorf("invalid PEP 440 epoch in %s: %w", version, err)
+		}
+	}
+	for _, part := range strings.Split(group("release"), ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PEP 440 release segment in %s: %w", version, err)
+		}
+		v.Release = append(v.Release, n)
+	}
+
+	if preLabel := group("pre_l"); preLabel != "" {
+		v.PreKind = pep440PreKinds[strings.ToLower(preLabel)]
+		if v.Pre, err = optionalNumber(group("pre_n")); err != nil {
+			return nil, fmt.Errorf("invalid PEP 440 prerelease number in %s: %w", version, err)
+		}
+	}
+	if postNumber := group("post_n1"); postNumber != "" || group("post_l") != "" {
+		if postNumber == "" {
+			postNumber = group("post_n2")
+		}
+		post, err := optionalNumber(postNumber)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PEP 440 post-release number in %s: %w", version, err)
+		}
+		v.Post = &post
+	}
+	if group("dev_l") != "" {
+		dev, err := optionalNumber(group("dev_n"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PEP 440 development release number in %s: %w", version, err)
+		}
+		v.Dev = &dev
+	}
+
+	if local := group("local"); local != "" {
+		parts := strings.FieldsFunc(strings.ToLower(local), func(r rune) bool { return r == '-' || r == '_' || r == '.' })
+		for i, part := range parts {
+			// Numeric local segments compare as numbers, so leading zeros don't matter
+			if n, err := strconv.Atoi(part); err == nil {
+				parts[i] = strconv.Itoa(n)
+			}
+		}
+		v.Local = strings.Join(parts, ".")
+	}
+	return v, nil
+}
+
+// optionalNumber parses a number that PEP 440 allows to be left out, in which case it is 0
+func optionalNumber(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// String returns the normalized form of the version
+func (v *PEP440Version) String() string {
+	var b strings.Builder
+	if v.Epoch != 0 {
+		fmt.Fprintf(&b, "%d!", v.Epoch)
+	}
+	for i, n := range v.Release {
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(strconv.Itoa(n))
+	}
+	if v.PreKind != "" {
+		fmt.Fprintf(&b, "%s%d", v.PreKind, v.Pre)
+	}
+	if v.Post != nil {
+		fmt.Fprintf(&b, ".post%d", *v.Post)
+	}
+	if v.Dev != nil {
+		fmt.Fprintf(&b, ".dev%d", *v.Dev)
+	}
+	if v.Local != "" {
+		b.WriteString("+")
+		b.WriteString(v.Local)
+	}
+	return b.String()
+}
+
+// NormalizePEP440 returns the normalized form of a PEP 440 version (e.g. "v1.0-RC.1" -> "1.0rc1")
+func NormalizePEP440(version string) (string, error) {
+	v, err := ParsePEP440(version)
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+// IsValidPEP440 checks if a string is a valid PEP 440 version, in normalized form or not
 // Full PEP 440 spec: https://peps.python.org/pep-0440/
 func IsValidPEP440(version string) bool {
-	// Simple regex for the versions we generate: X.Y.Z or X.Y.ZaN
-	pep440Regex := regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(a\d+)?$`)
-	return pep440Regex.MatchString(version)
+	_, err := ParsePEP440(version)
+	return err == nil
+}
+
+// pep440SemverLabels are the semver prerelease labels PEP 440 segments convert back to
+var pep440SemverLabels = map[string]string{
+	defaults.Pep440SegmentAlpha: defaults.PrereleaseID,
+	defaults.Pep440SegmentBeta:  "beta",
+	defaults.Pep440SegmentRC:    "rc",
+	defaults.Pep440SegmentDev:   "dev",
+}
+
+// ConvertFromPEP440 converts a PEP 440 version back to semver, reversing ConvertToPEP440WithOptions
+// A local label on a opts.BranchSegment version is the branch name ("1.2.0.dev3+feat.x" ->
+// "1.2.0-feat-x.3"). Other segments use a fixed label: a -> pre, b -> beta, rc -> rc and dev -> dev.
+// Post-releases and other local labels are kept as build metadata. The release segment is padded to
+// MAJOR.MINOR.PATCH, longer release segments and epochs have no semver equivalent.
+func ConvertFromPEP440(version string, opts PEP440Options) (string, error) {
+	v, err := ParsePEP440(version)
+	if err != nil {
+		return "", err
+	}
+	if v.Epoch != 0 {
+		return "", fmt.Errorf("cannot convert %s to semver: semver has no epochs", version)
+	}
+	if len(v.Release) > 3 {
+		return "", fmt.Errorf("cannot convert %s to semver: the release segment has more than 3 parts", version)
+	}
+	release := make([]int, 3)
+	copy(release, v.Release)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d.%d.%d", release[0], release[1], release[2])
+
+	// Prerelease identifiers: the prerelease segment first, then the development release
+	type segment struct {
+		kind   string
+		number int
+	}
+	var segments []segment
+	if v.PreKind != "" {
+		segments = append(segments, segment{v.PreKind, v.Pre})
+	}
+	if v.Dev != nil {
+		segments = append(segments, segment{defaults.Pep440SegmentDev, *v.Dev})
+	}
+
+	local := v.Local
+	var identifiers []string
+	for i, s := range segments {
+		label := pep440SemverLabels[s.kind]
+		if i == 0 && local != "" && s.kind == opts.BranchSegment {
+			label = strings.ReplaceAll(local, ".", "-")
+			local = ""
+		}
+		identifiers = append(identifiers, label, strconv.Itoa(s.number))
+	}
+	if len(identifiers) > 0 {
+		b.WriteString("-")
+		b.WriteString(strings.Join(identifiers, "."))
+	}
+
+	var metadata []string
+	if v.Post != nil {
+		metadata = append(metadata, "post", strconv.Itoa(*v.Post))
+	}
+	if local != "" {
+		metadata = append(metadata, local)
+	}
+	if len(metadata) > 0 {
+		b.WriteString("+")
+		b.WriteString(strings.Join(metadata, "."))
+	}
+	return b.String(), nil
 }
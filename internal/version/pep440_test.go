@@ -53,6 +53,30 @@ func TestConvertToPEP440(t *testing.T) {
 			expected:    "1.0.0a0",
 			shouldError: false,
 		},
+		{
+			name:        "release candidate",
+			semver:      "2.0.0-rc.2",
+			expected:    "2.0.0rc2",
+			shouldError: false,
+		},
+		{
+			name:        "beta",
+			semver:      "2.0.0-beta.1",
+			expected:    "2.0.0b1",
+			shouldError: false,
+		},
+		{
+			name:        "build metadata dropped",
+			semver:      "1.0.0-pre.5+sha.abc",
+			expected:    "1.0.0a5",
+			shouldError: false,
+		},
+		{
+			name:        "invalid - build number not a number",
+			semver:      "1.0.0-pre.x",
+			expected:    "",
+			shouldError: true,
+		},
 		{
 			name:        "invalid - missing build number",
 			semver:      "1.0.0-pre",
@@ -114,23 +138,48 @@ func TestIsValidPEP440(t *testing.T) {
 			valid:   true,
 		},
 		{
-			name:    "invalid - semver prerelease format",
+			name:    "valid - semver prerelease spelling",
 			version: "1.0.0-pre.1",
-			valid:   false,
+			valid:   true,
 		},
 		{
-			name:    "invalid - missing patch",
+			name:    "valid - two part release",
 			version: "1.0",
-			valid:   false,
+			valid:   true,
 		},
 		{
-			name:    "invalid - beta instead of alpha",
+			name:    "valid - beta",
 			version: "1.0.0b1",
-			valid:   false,
+			valid:   true,
 		},
 		{
-			name:    "invalid - leading zeros",
+			name:    "valid - leading zeros",
 			version: "01.02.03",
+			valid:   true,
+		},
+		{
+			name:    "valid - all segments",
+			version: "1!2.0.0rc1.post2.dev3+feat.x",
+			valid:   true,
+		},
+		{
+			name:    "invalid - unknown prerelease label",
+			version: "1.0.0-feature.1",
+			valid:   false,
+		},
+		{
+			name:    "invalid - empty local label",
+			version: "1.0.0+",
+			valid:   false,
+		},
+		{
+			name:    "invalid - two prerelease segments",
+			version: "1.0.0a1b2",
+			valid:   false,
+		},
+		{
+			name:    "invalid - empty",
+			version: "",
 			valid:   false,
 		},
 	}
@@ -144,3 +193,129 @@ func TestIsValidPEP440(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertToPEP440WithOptions(t *testing.T) {
+	devLocal := PEP440Options{BranchSegment: "dev", Labels: DefaultPEP440Options().Labels, LocalLabel: true}
+
+	tests := []struct {
+		name     string
+		semver   string
+		opts     PEP440Options
+		expected string
+	}{
+		{"branch as dev release with local label", "1.2.0-feat-x.3", devLocal, "1.2.0.dev3+feat.x"},
+		{"other branch doesn't collide", "1.2.0-feat-y.3", devLocal, "1.2.0.dev3+feat.y"},
+		{"rc keeps its segment", "1.2.0-rc.1", devLocal, "1.2.0rc1"},
+		{"pre is an alpha release without local label", "1.2.0-pre.4", devLocal, "1.2.0a4"},
+		{"release unchanged", "1.2.0", devLocal, "1.2.0"},
+		{"custom label", "1.2.0-preview.2", PEP440Options{BranchSegment: "a", Labels: map[string]string{"preview": "b"}}, "1.2.0b2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ConvertToPEP440WithOptions(tt.semver, tt.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ConvertToPEP440WithOptions(%q) = %q, want %q", tt.semver, result, tt.expected)
+			}
+			if !IsValidPEP440(result) {
+				t.Errorf("Result %q is not valid PEP 440", result)
+			}
+		})
+	}
+}
+
+func TestNormalizePEP440(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.0.0", "1.0.0"},
+		{"v1.0-RC.1", "1.0rc1"},
+		{"1.0.0-pre.1", "1.0.0rc1"},
+		{"1.0alpha", "1.0a0"},
+		{"1.0.0-beta-2", "1.0.0b2"},
+		{"1.0-1", "1.0.post1"},
+		{"1.0.rev", "1.0.post0"},
+		{"1.0.0_dev", "1.0.0.dev0"},
+		{"01.02.03", "1.2.3"},
+		{"0!1.0", "1.0"},
+		{"2!1.0c1", "2!1.0rc1"},
+		{"1.0+Ubuntu-01_Fix", "1.0+ubuntu.1.fix"},
+		{" 1.0.0a1.post2.dev3 ", "1.0.0a1.post2.dev3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			result, err := NormalizePEP440(tt.version)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("NormalizePEP440(%q) = %q, want %q", tt.version, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertFromPEP440(t *testing.T) {
+	devLocal := PEP440Options{BranchSegment: "dev", Labels: DefaultPEP440Options().Labels, LocalLabel: true}
+
+	tests := []struct {
+		name        string
+		version     string
+		opts        PEP440Options
+		expected    string
+		shouldError bool
+	}{
+		{"release", "1.2.0", DefaultPEP440Options(), "1.2.0", false},
+		{"short release padded", "1.2", DefaultPEP440Options(), "1.2.0", false},
+		{"alpha to pre", "1.2.0a4", DefaultPEP440Options(), "1.2.0-pre.4", false},
+		{"release candidate", "1.2.0rc1", DefaultPEP440Options(), "1.2.0-rc.1", false},
+		{"branch from local label", "1.2.0.dev3+feat.x", devLocal, "1.2.0-feat-x.3", false},
+		{"dev without local label", "1.2.0.dev3", devLocal, "1.2.0-dev.3", false},
+		{"prerelease with dev release", "1.2.0b1.dev2", DefaultPEP440Options(), "1.2.0-beta.1.dev.2", false},
+		{"post release as metadata", "1.2.0.post1+local", DefaultPEP440Options(), "1.2.0+post.1.local", false},
+		{"epoch", "1!1.2.0", DefaultPEP440Options(), "", true},
+		{"four part release", "1.2.0.1", DefaultPEP440Options(), "", true},
+		{"invalid", "1.2.0-feature", DefaultPEP440Options(), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ConvertFromPEP440(tt.version, tt.opts)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected error for input %q, got %q", tt.version, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ConvertFromPEP440(%q) = %q, want %q", tt.version, result, tt.expected)
+			}
+			if !IsValidSemver(result) {
+				t.Errorf("Result %q is not valid semver", result)
+			}
+		})
+	}
+
+	// Converting there and back gives the original version
+	for _, semver := range []string{"1.0.0-pre.5", "2.0.0-rc.2", "1.2.0-feat-x.3", "1.2.0"} {
+		pep440, err := ConvertToPEP440WithOptions(semver, devLocal)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		back, err := ConvertFromPEP440(pep440, devLocal)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if back != semver {
+			t.Errorf("Round trip of %q gave %q via %q", semver, back, pep440)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package version
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/git"
+)
+
+// Result is the calculated version of a commit in all supported formats, along with the details
+// of how it was calculated. Output templates are rendered against it.
+type Result struct {
+	Semver           string
+	SemverWithPrefix string
+	Pep440           string
+	Pep440WithPrefix string
+	Major            int
+	Minor            int
+	Patch            int
+	Prerelease       string // prerelease label (e.g. "pre" or the sanitized branch name), empty for releases
+	Build            int    // number after the prerelease label (e.g. 3 in 1.0.1-login.3)
+	IsRelease        bool
+
+	Branch          string // branch the commit was versioned as, may be empty for tagged commits
+	SanitizedBranch string
+	IsMainBranch    bool
+	MainBranch      string
+	CIBranchUsed    bool // the branch came from CI environment variables
+
+	SHA             string
+	ShortSHA        string
+	CommitTime      time.Time // author date of the commit
+	BaseTag         string    // tag the version is based on, empty when based on the initial version
+	BaseVersion     string    // version of BaseTag, without the tag prefix
+	CommitsSinceTag int
+
+	BranchCommits          int  // commits on a feature branch since it diverged from main
+	MainCommitsSinceBranch int  // commits on main since a feature branch diverged
+	Outdated               bool // main has moved on since a feature branch diverged
+
+	MainBranchDivergence *git.MainBranchDivergence // set when local and remote main differ
+
+	// pep440Err is set when the version can't be converted to PEP 440 (e.g. a "1.0.0-rc" tag),
+	// which only matters for the formats that need it
+	pep440Err error
+
+	// calc is the calculation the result was built from, nil for results built by hand
+	calc *calculation
+}
+
+// newResult builds the result for a calculation, converting the version to every format
+func newResult(calc calculation, cfg *config.Config) (*Result, error) {
+	parsedVersion, err := parseVersion(calc.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version: %w", err)
+	}
+	pep440Opts, err := pep440Options(cfg)
+	if err != nil {
+		return nil, err
+	}
+	pep440Version, pep440Err := ConvertToPEP440WithOptions(calc.Version, pep440Opts)
+	prerelease, build := splitPrerelease(calc.Version)
+
+	baseVersion := ""
+	if calc.BaseTag != "" {
+		tagPrefix := ""
+		if cfg.TagPrefix != nil {
+			tagPrefix = *cfg.TagPrefix
+		}
+		baseVersion = git.StripTagPrefix(calc.BaseTag, tagPrefix)
+	}
+
+	sanitizedBranch := ""
+	if calc.Branch != "" {
+		sanitizedBranch = git.SanitizeBranchName(calc.Branch)
+	}
+
+	result := &Result{
+		Semver:                 calc.Version,
+		SemverWithPrefix:       applyVersionPrefix(calc.Version, cfg),
+		Pep440:                 pep440Version,
+		Pep440WithPrefix:       applyVersionPrefix(pep440Version, cfg),
+		Major:                  parsedVersion.Major,
+		Minor:                  parsedVersion.Minor,
+		Patch:                  parsedVersion.Patch,
+		Prerelease:             prerelease,
+		Build:                  build,
+		IsRelease:              !strings.Contains(calc.Version, "-"),
+		Branch:                 calc.Branch,
+		SanitizedBranch:        sanitizedBranch,
+		IsMainBranch:           calc.IsMainBranch,
+		MainBranch:             calc.MainBranch,
+		CIBranchUsed:           calc.CIBranchUsed,
+		SHA:                    calc.SHA,
+		ShortSHA:               shortSHA(calc.SHA),
+		CommitTime:             calc.CommitTime,
+		BaseTag:                calc.BaseTag,
+		BaseVersion:            baseVersion,
+		CommitsSinceTag:        calc.CommitsSinceTag,
+		BranchCommits:          calc.BranchCommits,
+		MainCommitsSinceBranch: calc.MainCommitsSinceBranch,
+		Outdated:               calc.Outdated,
+		MainBranchDivergence:   calc.MainBranchDivergence,
+		pep440Err:              pep440Err,
+		calc:                   &calc,
+	}
+	if pep440Err != nil {
+		result.Pep440WithPrefix = ""
+	}
+	return result, nil
+}
+
+// isTagged reports whether the commit itself is tagged, so the version is the tag
+func (r *Result) isTagged() bool {
+	return r.BaseTag != "" && r.CommitsSinceTag == 0
+}
+
+// splitPrerelease splits the prerelease part of a semver version into its label and build number
+// "1.0.1-login.3" gives ("login", 3). A prerelease without a numeric last identifier (e.g. "rc")
+// is returned as the label with build number 0.
+func splitPrerelease(version string) (string, int) {
+	version, _, _ = strings.Cut(version, "+")
+	_, prerelease, found := strings.Cut(version, "-")
+	if !found {
+		return "", 0
+	}
+
+	if lastDot := strings.LastIndex(prerelease, "."); lastDot != -1 {
+		if build, err := strconv.Atoi(prerelease[lastDot+1:]); err == nil {
+			return prerelease[:lastDot], build
+		}
+	}
+	return prerelease, 0
+}
+
+// OutputField is a single named value of the JSON output, for output formats that are flat lists
+// of variables
+type OutputField struct {
+	Name  string // JSON field name (e.g. "semverWithPrefix")
+	Value string
+	Type  string // Go type of the value: "string", "int" or "bool"
+}
+
+// OutputFields returns the fields of the JSON output (mode "json") as strings, in the same order
+// Nested objects are flattened by joining the names (e.g. "mainBranchDivergenceAhead") and are
+// left out when they aren't set.
+func OutputFields(result *Result) []OutputField {
+	return flattenOutputFields("", reflect.ValueOf(newVersionOutput(result)))
+}
+
+// flattenOutputFields returns the JSON fields of a struct value, prefixing their names with prefix
+func flattenOutputFields(prefix string, value reflect.Value) []OutputField {
+	var fields []OutputField
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + strings.ToUpper(name[:1]) + name[1:]
+		}
+
+		fieldValue := value.Field(i)
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Struct {
+			fields = append(fields, flattenOutputFields(name, fieldValue)...)
+			continue
+		}
+		fields = append(fields, OutputField{Name: name, Value: fmt.Sprint(fieldValue.Interface()), Type: fieldValue.Kind().String()})
+	}
+	return fields
+}
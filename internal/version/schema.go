@@ -0,0 +1,25 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+)
+
+// GenerateOutputSchema generates a JSON schema for the JSON output (mode "json")
+func GenerateOutputSchema() (string, error) {
+	reflector := jsonschema.Reflector{
+		AllowAdditionalProperties: false,
+		DoNotReference:            true,
+	}
+	schema := reflector.Reflect(&VersionOutput{})
+	schema.Title = "Autoversion Output"
+	schema.Description = fmt.Sprintf("JSON output of autoversion (schemaVersion %d)", OutputSchemaVersion)
+
+	schemaBytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return string(schemaBytes), nil
+}
@@ -1,6 +1,10 @@
 package version
 
-import "regexp"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
 
 // semverRegex matches semantic versions according to semver 2.0.0
 // Format: MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]
@@ -10,3 +14,90 @@ var semverRegex = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(
 func IsValidSemver(version string) bool {
 	return semverRegex.MatchString(version)
 }
+
+// CompareSemver compares two semantic versions according to semver 2.0.0 precedence rules
+// Returns -1 if a < b, 0 if a == b and 1 if a > b. Build metadata is ignored.
+func CompareSemver(a, b string) (int, error) {
+	aParts := semverRegex.FindStringSubmatch(a)
+	if aParts == nil {
+		return 0, fmt.Errorf("invalid semver: %s", a)
+	}
+	bParts := semverRegex.FindStringSubmatch(b)
+	if bParts == nil {
+		return 0, fmt.Errorf("invalid semver: %s", b)
+	}
+
+	// Compare MAJOR.MINOR.PATCH numerically
+	for i := 1; i <= 3; i++ {
+		if c := compareNumeric(aParts[i], bParts[i]); c != 0 {
+			return c, nil
+		}
+	}
+
+	// A version without prerelease has higher precedence than one with a prerelease
+	aPre, bPre := aParts[4], bParts[4]
+	switch {
+	case aPre == "" && bPre == "":
+		return 0, nil
+	case aPre == "":
+		return 1, nil
+	case bPre == "":
+		return -1, nil
+	}
+
+	// Compare prerelease identifiers from left to right
+	aIDs := strings.Split(aPre, ".")
+	bIDs := strings.Split(bPre, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum := isNumericIdentifier(aIDs[i])
+		bNum := isNumericIdentifier(bIDs[i])
+		var c int
+		switch {
+		case aNum && bNum:
+			c = compareNumeric(aIDs[i], bIDs[i])
+		case aNum:
+			// Numeric identifiers have lower precedence than alphanumeric ones
+			c = -1
+		case bNum:
+			c = 1
+		default:
+			c = strings.Compare(aIDs[i], bIDs[i])
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+
+	// A larger set of prerelease fields has higher precedence if all preceding ones are equal
+	switch {
+	case len(aIDs) < len(bIDs):
+		return -1, nil
+	case len(aIDs) > len(bIDs):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// isNumericIdentifier returns true if the identifier only consists of digits
+func isNumericIdentifier(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// compareNumeric compares two strings of digits without leading zeros by numeric value
+func compareNumeric(a, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
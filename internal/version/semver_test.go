@@ -0,0 +1,85 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected int
+	}{
+		{
+			name:     "equal versions",
+			a:        "1.2.3",
+			b:        "1.2.3",
+			expected: 0,
+		},
+		{
+			name:     "major wins",
+			a:        "2.0.0",
+			b:        "1.9.9",
+			expected: 1,
+		},
+		{
+			name:     "numeric comparison of patch",
+			a:        "1.0.9",
+			b:        "1.0.10",
+			expected: -1,
+		},
+		{
+			name:     "release is greater than prerelease",
+			a:        "1.0.0",
+			b:        "1.0.0-pre.5",
+			expected: 1,
+		},
+		{
+			name:     "numeric prerelease identifiers",
+			a:        "1.0.0-pre.2",
+			b:        "1.0.0-pre.10",
+			expected: -1,
+		},
+		{
+			name:     "alphanumeric prerelease identifiers",
+			a:        "1.0.0-beta.1",
+			b:        "1.0.0-alpha.1",
+			expected: 1,
+		},
+		{
+			name:     "numeric identifier is lower than alphanumeric",
+			a:        "1.0.0-1",
+			b:        "1.0.0-alpha",
+			expected: -1,
+		},
+		{
+			name:     "more prerelease fields wins",
+			a:        "1.0.0-alpha.1",
+			b:        "1.0.0-alpha",
+			expected: 1,
+		},
+		{
+			name:     "build metadata is ignored",
+			a:        "1.0.0+abc",
+			b:        "1.0.0+def",
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CompareSemver(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("CompareSemver(%q, %q) unexpected error: %v", tt.a, tt.b, err)
+			}
+			if result != tt.expected {
+				t.Errorf("CompareSemver(%q, %q) = %d, want %d", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := CompareSemver("1.0", "1.0.0"); err == nil {
+		t.Errorf("CompareSemver with invalid semver should return an error")
+	}
+}
@@ -0,0 +1,114 @@
+package version
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/git"
+)
+
+// TagOptions configures how Tag creates the release tag
+type TagOptions struct {
+	Message string // tag message, defaults to "Release <version>"
+	Sign    bool
+	Force   bool // tag a dirty worktree or a branch other than main, and replace an existing tag
+	DryRun  bool // check everything, but don't create or push the tag
+	Push    bool
+	Remote  string // remote to push to, defaults to the first configured remote
+}
+
+// TagResult is the tag created by Tag
+type TagResult struct {
+	Name    string
+	Version string
+	SHA     string
+	Remote  string // remote the tag was pushed to, empty when not pushed
+	Created bool   // false for a dry run
+}
+
+// Tag creates an annotated tag for the calculated version of HEAD
+// The tag name is the configured tagPrefix followed by the semver version. Unless forced, the
+// worktree must be clean, HEAD must be on a main branch and the tag must not exist yet. With
+// mainBranchBehavior "pre", the prerelease of a main branch commit is tagged as its release version.
+func Tag(cfg *config.Config, opts TagOptions) (*TagResult, error) {
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.HeadHash()
+	if err != nil {
+		return nil, err
+	}
+	calc, err := calculate(repo, cfg, head, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if !calc.IsMainBranch && !opts.Force {
+		return nil, fmt.Errorf("HEAD is on branch '%s', not a main branch %v (use --force to tag it anyway)", calc.Branch, configuredMainBranches(cfg))
+	}
+	version := calc.Version
+	if calc.IsMainBranch && calc.mainBranchBehavior == "pre" {
+		version = semverCore(version)
+		if version != calc.Version {
+			log("Tagging the release version %s of prerelease %s", version, calc.Version)
+		}
+	}
+	return tagCommit(repo, cfg, calc.SHA, version, opts)
+}
+
+// tagCommit creates an annotated tag for a version on a commit, after checking the worktree and
+// that the tag doesn't exist yet
+func tagCommit(repo *git.Repo, cfg *config.Config, sha, version string, opts TagOptions) (*TagResult, error) {
+	tagPrefix := ""
+	if cfg.TagPrefix != nil {
+		tagPrefix = *cfg.TagPrefix
+	}
+	result := &TagResult{Name: tagPrefix + version, Version: version, SHA: sha}
+
+	clean, err := repo.IsClean()
+	if err != nil {
+		return nil, err
+	}
+	if !clean && !opts.Force {
+		return nil, fmt.Errorf("the worktree has uncommitted changes (use --force to tag it anyway)")
+	}
+	exists, err := repo.TagExists(result.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists && !opts.Force {
+		return nil, fmt.Errorf("tag %s already exists (use --force to move it)", result.Name)
+	}
+
+	remote := ""
+	if opts.Push {
+		remote = opts.Remote
+		if remote == "" {
+			remote = configuredRemotes(cfg)[0]
+		}
+	}
+	if opts.DryRun {
+		result.Remote = remote
+		return result, nil
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = "Release " + version
+	}
+	log("Creating tag %s on %s", result.Name, sha)
+	if err := repo.CreateTag(result.Name, plumbing.NewHash(sha), git.TagOptions{Message: message, Sign: opts.Sign, Force: opts.Force}); err != nil {
+		return nil, err
+	}
+	result.Created = true
+
+	if remote != "" {
+		log("Pushing tag %s to %s", result.Name, remote)
+		if err := repo.PushTag(remote, result.Name, opts.Force); err != nil {
+			return result, err
+		}
+		result.Remote = remote
+	}
+	return result, nil
+}
@@ -0,0 +1,49 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+	"github.com/trondhindenes/autoversion/internal/git"
+)
+
+// templateFuncs returns the helper functions available in output templates
+// They take the value last so they can be used in pipelines, e.g. {{.Branch | sanitize | truncate 20}}.
+// pep440 converts with the configured PEP 440 options, like mode "pep440" does.
+func templateFuncs(pep440Opts PEP440Options) template.FuncMap {
+	return template.FuncMap{
+		"sanitize": git.SanitizeBranchName,
+		"truncate": func(length int, s string) string {
+			if runes := []rune(s); length >= 0 && len(runes) > length {
+				return string(runes[:length])
+			}
+			return s
+		},
+		"pep440": func(semver string) (string, error) {
+			return ConvertToPEP440WithOptions(semver, pep440Opts)
+		},
+		"lower":   strings.ToLower,
+		"upper":   strings.ToUpper,
+		"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	}
+}
+
+// RenderTemplate renders a Go text/template against a version result
+func RenderTemplate(text string, result *Result, cfg *config.Config) (string, error) {
+	pep440Opts, err := pep440Options(cfg)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs(pep440Opts)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, result); err != nil {
+		return "", fmt.Errorf("failed to render output template: %w", err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,126 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/trondhindenes/autoversion/internal/config"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	result := &Result{
+		Semver:          "1.2.4-feature-login.3",
+		Pep440:          "1.2.4a3",
+		Major:           1,
+		Minor:           2,
+		Patch:           4,
+		Prerelease:      "feature-login",
+		Build:           3,
+		Branch:          "feature/Feature-Login",
+		SanitizedBranch: "feature-login",
+		SHA:             "0123456789abcdef0123456789abcdef01234567",
+		ShortSHA:        "0123456",
+		BaseTag:         "v1.2.3",
+	}
+
+	devSegment := "dev"
+	tests := []struct {
+		name     string
+		template string
+		cfg      config.Config
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "fields",
+			template: "{{.Major}}.{{.Minor}}-{{.ShortSHA}}",
+			expected: "1.2-0123456",
+		},
+		{
+			name:     "conditional on release",
+			template: "{{if .IsRelease}}{{.Semver}}{{else}}{{.Prerelease}}.{{.Build}}{{end}}",
+			expected: "feature-login.3",
+		},
+		{
+			name:     "sanitize and truncate",
+			template: "{{.Branch | sanitize | truncate 7}}",
+			expected: "feature",
+		},
+		{
+			name:     "truncate longer than value",
+			template: "{{truncate 100 .BaseTag}}",
+			expected: "v1.2.3",
+		},
+		{
+			name:     "truncate multi-byte characters",
+			template: `{{truncate 2 "héllo"}}`,
+			expected: "hé",
+		},
+		{
+			name:     "pep440",
+			template: `{{pep440 "2.0.0-pre.4"}}`,
+			expected: "2.0.0a4",
+		},
+		{
+			name:     "pep440 with the configured options",
+			template: `{{pep440 .Semver}}`,
+			cfg:      config.Config{Pep440BranchSegment: &devSegment},
+			expected: "1.2.4.dev3",
+		},
+		{
+			name:     "replace and upper",
+			template: `{{.SanitizedBranch | replace "-" "_" | upper}}`,
+			expected: "FEATURE_LOGIN",
+		},
+		{
+			name:     "unknown field",
+			template: "{{.Nope}}",
+			wantErr:  true,
+		},
+		{
+			name:     "syntax error",
+			template: "{{.Major",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := RenderTemplate(tt.template, result, &tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %q", output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestSplitPrerelease(t *testing.T) {
+	tests := []struct {
+		version string
+		label   string
+		build   int
+	}{
+		{"1.0.0", "", 0},
+		{"1.0.1-pre.0", "pre", 0},
+		{"1.0.1-feature-login.12", "feature-login", 12},
+		{"2.0.0-rc", "rc", 0},
+		{"2.0.0-rc.1+build.5", "rc", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			label, build := splitPrerelease(tt.version)
+			if label != tt.label || build != tt.build {
+				t.Errorf("splitPrerelease(%q) = (%q, %d), expected (%q, %d)", tt.version, label, build, tt.label, tt.build)
+			}
+		})
+	}
+}
@@ -6,23 +6,88 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/trondhindenes/autoversion/internal/ci"
 	"github.com/trondhindenes/autoversion/internal/config"
 	"github.com/trondhindenes/autoversion/internal/defaults"
 	"github.com/trondhindenes/autoversion/internal/git"
 )
 
+// OutputSchemaVersion is the version of the JSON output structure
+// It is increased when fields are renamed or removed, or change meaning. Adding fields doesn't change it.
+const OutputSchemaVersion = 1
+
 // VersionOutput represents the JSON output structure for version information
 type VersionOutput struct {
-	Semver           string `json:"semver"`
-	SemverWithPrefix string `json:"semverWithPrefix"`
-	Pep440           string `json:"pep440"`
-	Pep440WithPrefix string `json:"pep440WithPrefix"`
-	Major            int    `json:"major"`
-	Minor            int    `json:"minor"`
-	Patch            int    `json:"patch"`
-	IsRelease        bool   `json:"isRelease"`
+	SchemaVersion    int    `json:"schemaVersion" jsonschema:"description=Version of this output structure"`
+	Semver           string `json:"semver" jsonschema:"description=Semantic version"`
+	SemverWithPrefix string `json:"semverWithPrefix" jsonschema:"description=Semantic version with the configured versionPrefix"`
+	Pep440           string `json:"pep440" jsonschema:"description=PEP 440 version"`
+	Pep440WithPrefix string `json:"pep440WithPrefix" jsonschema:"description=PEP 440 version with the configured versionPrefix"`
+	Major            int    `json:"major" jsonschema:"description=Major version"`
+	Minor            int    `json:"minor" jsonschema:"description=Minor version"`
+	Patch            int    `json:"patch" jsonschema:"description=Patch version"`
+	IsRelease        bool   `json:"isRelease" jsonschema:"description=True when the version has no prerelease part"`
+
+	Prerelease      string `json:"prerelease" jsonschema:"description=Prerelease label (e.g. 'pre' or the sanitized branch name). Empty for releases"`
+	Build           int    `json:"build" jsonschema:"description=Build number after the prerelease label. 0 for releases"`
+	Branch          string `json:"branch" jsonschema:"description=Branch the commit was versioned as. May be empty for tagged commits"`
+	SanitizedBranch string `json:"sanitizedBranch" jsonschema:"description=Branch name sanitized for use as a prerelease label"`
+	IsMainBranch    bool   `json:"isMainBranch" jsonschema:"description=True when the branch is one of the main branches"`
+	CIBranchUsed    bool   `json:"ciBranchUsed" jsonschema:"description=True when the branch was detected from CI environment variables"`
+	SHA             string `json:"sha" jsonschema:"description=Full SHA of the versioned commit"`
+	BaseTag         string `json:"baseTag" jsonschema:"description=Tag the version is based on. Empty when based on the initial version"`
+	CommitsSinceTag int    `json:"commitsSinceTag" jsonschema:"description=Number of commits since the base tag"`
+	OutdatedBase    bool   `json:"outdatedBase" jsonschema:"description=True when main has moved on since the feature branch diverged (see outdatedBaseCheckMode)"`
+
+	MainBranchDivergence *MainBranchDivergenceOutput `json:"mainBranchDivergence,omitempty" jsonschema:"description=Set when the local main branch and its remote-tracking branch point to different commits"`
+}
+
+// MainBranchDivergenceOutput is included in the JSON output when the local main branch and its
+// remote-tracking branch point to different commits
+type MainBranchDivergenceOutput struct {
+	LocalRef  string `json:"localRef" jsonschema:"description=Local main branch"`
+	RemoteRef string `json:"remoteRef" jsonschema:"description=Remote-tracking main branch"`
+	UsedRef   string `json:"usedRef" jsonschema:"description=The main branch the version was calculated against (see mainBranchSource)"`
+	Ahead     int    `json:"ahead" jsonschema:"description=Commits on the local branch that are not on the remote-tracking branch"`
+	Behind    int    `json:"behind" jsonschema:"description=Commits on the remote-tracking branch that are not on the local branch"`
+}
+
+// newVersionOutput builds the JSON output for a result
+func newVersionOutput(result *Result) VersionOutput {
+	output := VersionOutput{
+		SchemaVersion:    OutputSchemaVersion,
+		Semver:           result.Semver,
+		SemverWithPrefix: result.SemverWithPrefix,
+		Pep440:           result.Pep440,
+		Pep440WithPrefix: result.Pep440WithPrefix,
+		Major:            result.Major,
+		Minor:            result.Minor,
+		Patch:            result.Patch,
+		IsRelease:        result.IsRelease,
+		Prerelease:       result.Prerelease,
+		Build:            result.Build,
+		Branch:           result.Branch,
+		SanitizedBranch:  result.SanitizedBranch,
+		IsMainBranch:     result.IsMainBranch,
+		CIBranchUsed:     result.CIBranchUsed,
+		SHA:              result.SHA,
+		BaseTag:          result.BaseTag,
+		CommitsSinceTag:  result.CommitsSinceTag,
+		OutdatedBase:     result.Outdated,
+	}
+	if d := result.MainBranchDivergence; d != nil {
+		output.MainBranchDivergence = &MainBranchDivergenceOutput{
+			LocalRef:  d.LocalRef,
+			RemoteRef: d.RemoteRef,
+			UsedRef:   d.UsedRef,
+			Ahead:     d.Ahead,
+			Behind:    d.Behind,
+		}
+	}
+	return output
 }
 
 // log writes a log message to stderr
@@ -30,6 +95,13 @@ func log(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, format+"\n", args...)
 }
 
+// logFunc writes a log message
+type logFunc func(format string, args ...interface{})
+
+// discardLog drops log messages. Batch operations that calculate many versions in a row use it
+// to keep the per-commit calculation details out of the way.
+func discardLog(format string, args ...interface{}) {}
+
 // Version represents a semantic version
 type Version struct {
 	Major      int
@@ -70,93 +142,286 @@ func Calculate(mainBranch, tagPrefix string) (string, error) {
 
 // CalculateWithConfig calculates the version based on the current git state and configuration
 func CalculateWithConfig(cfg *config.Config) (string, error) {
+	return CalculateForRef(cfg, "", "")
+}
+
+// CalculateForRef calculates the version for an arbitrary commit without checking it out
+// ref can be a commit SHA, a branch, a remote branch or a tag. An empty ref means HEAD.
+// branch is the branch name to version the commit as. If empty, it is derived from ref when
+// ref names a branch, or set to the main branch when the commit is part of main's history.
+// The version is formatted according to the configured outputTemplate or mode.
+func CalculateForRef(cfg *config.Config, ref, branch string) (string, error) {
+	result, err := CalculateResult(cfg, ref, branch)
+	if err != nil {
+		return "", err
+	}
+	return FormatResult(result, cfg)
+}
+
+// CalculateResult calculates the version for a commit like CalculateForRef, and returns it in all
+// supported formats along with the details of how it was calculated
+func CalculateResult(cfg *config.Config, ref, branch string) (*Result, error) {
+	calc, err := calculateForRef(cfg, ref, branch)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(calc, cfg)
+}
+
+// FormatResult formats a result according to the configured outputTemplate or mode
+func FormatResult(result *Result, cfg *config.Config) (string, error) {
+	// An output template replaces the mode formatting entirely
+	if cfg.OutputTemplate != nil && *cfg.OutputTemplate != "" {
+		output, err := RenderTemplate(*cfg.OutputTemplate, result, cfg)
+		if err != nil {
+			return "", err
+		}
+		log("Rendered output template: %s", *cfg.OutputTemplate)
+		log("Final version: %s", output)
+		return output, nil
+	}
+
+	// Apply mode conversion (which handles prefix internally for JSON mode)
+	modeVersion, err := applyVersionMode(result, cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply version mode: %w", err)
+	}
+	// For modes that output a single version, apply prefix here
+	mode := defaults.DefaultMode
+	if cfg.Mode != nil && *cfg.Mode != "" {
+		mode = *cfg.Mode
+	}
+	if !isJSONMode(mode) {
+		prefixed := applyVersionPrefix(modeVersion, cfg)
+		if prefixed != modeVersion {
+			log("Applied version prefix: %s -> %s", modeVersion, prefixed)
+		}
+		log("Final version: %s", prefixed)
+		return prefixed, nil
+	}
+	log("Final version: %s", modeVersion)
+	return modeVersion, nil
+}
+
+// ConvertResult converts a result to the version of a mode that outputs a single version
+// ("semver", "pep440", "maven", "deb" or "rpm"), ignoring the configured mode, outputTemplate and
+// versionPrefix
+func ConvertResult(result *Result, cfg *config.Config, mode string) (string, error) {
+	if isJSONMode(mode) {
+		return "", fmt.Errorf("mode '%s' doesn't output a single version", mode)
+	}
+	modeCfg := *cfg
+	modeCfg.Mode = &mode
+	return applyVersionMode(result, &modeCfg)
+}
+
+// calculateForRef opens the repository and calculates the semver version of a ref (or HEAD)
+func calculateForRef(cfg *config.Config, ref, branch string) (calculation, error) {
+	repo, err := openRepo(cfg)
+	if err != nil {
+		return calculation{}, err
+	}
+
+	// Determine the commit to calculate the version for
+	var startHash plumbing.Hash
+	if ref != "" {
+		startHash, err = repo.ResolveRef(ref)
+		if err != nil {
+			return calculation{}, err
+		}
+		log("Calculating version for ref %s (%s)", ref, startHash.String())
+	} else {
+		startHash, err = repo.HeadHash()
+		if err != nil {
+			return calculation{}, err
+		}
+	}
+
+	divergence, err := checkMainBranchDivergence(repo, cfg)
+	if err != nil {
+		return calculation{}, err
+	}
+
+	calc, err := calculate(repo, cfg, startHash, ref, branch)
+	if err != nil {
+		return calculation{}, err
+	}
+	commit, err := repo.GetCommit(startHash)
+	if err != nil {
+		return calculation{}, err
+	}
+	calc.CommitTime = commit.Date
+	calc.MainBranchDivergence = divergence
+	return calc, nil
+}
+
+// openRepo opens the git repository in the current directory, verifies it has full history and
+// applies the configured remote settings
+func openRepo(cfg *config.Config) (*git.Repo, error) {
 	log("Opening git repository...")
 	repo, err := git.OpenRepo(".")
 	if err != nil {
-		return "", fmt.Errorf("failed to open git repository: %w", err)
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
 	}
 
 	// Check if this is a shallow clone
 	log("Checking if repository is a shallow clone...")
 	isShallow, err := repo.IsShallow()
 	if err != nil {
-		return "", fmt.Errorf("failed to check if repository is shallow: %w", err)
+		return nil, fmt.Errorf("failed to check if repository is shallow: %w", err)
 	}
 	if isShallow {
-		return "", fmt.Errorf("autoversion does not work with shallow clones. Please use 'git fetch --unshallow' to convert to a full clone, or clone without --depth")
+		return nil, fmt.Errorf("autoversion does not work with shallow clones. Please use 'git fetch --unshallow' to convert to a full clone, or clone without --depth")
 	}
 	log("Repository is not a shallow clone")
 
-	// Check for tags first - tags take precedence over everything
-	log("Checking for git tags on current commit...")
-	tag, err := repo.GetTagOnCurrentCommit()
+	remotes := configuredRemotes(cfg)
+	repo.SetRemotes(remotes)
+
+	mainBranchSource := configuredMainBranchSource(cfg)
+	validSource := false
+	for _, valid := range defaults.ValidMainBranchSources {
+		if mainBranchSource == valid {
+			validSource = true
+			break
+		}
+	}
+	if !validSource {
+		return nil, fmt.Errorf("invalid mainBranchSource '%s': must be one of %v", mainBranchSource, defaults.ValidMainBranchSources)
+	}
+	repo.SetPreferRemoteMainBranch(mainBranchSource == defaults.MainBranchSourceRemote)
+	log("Using remotes %v, main branch source: %s", remotes, mainBranchSource)
+
+	return repo, nil
+}
+
+// configuredRemotes returns the configured remotes, in order of preference
+func configuredRemotes(cfg *config.Config) []string {
+	if len(cfg.Remotes) > 0 {
+		return cfg.Remotes
+	}
+	if cfg.Remote != "" {
+		return []string{cfg.Remote}
+	}
+	return []string{defaults.DefaultRemote}
+}
+
+// configuredMainBranchSource returns where the main branch is resolved from: "local" or "remote"
+func configuredMainBranchSource(cfg *config.Config) string {
+	if cfg.MainBranchSource != nil && *cfg.MainBranchSource != "" {
+		return *cfg.MainBranchSource
+	}
+	return defaults.DefaultMainBranchSource
+}
+
+// checkMainBranchDivergence compares the local main branch with its remote-tracking branch
+// Versions are counted from whichever of them mainBranchSource picks, so when they point to
+// different commits a developer's local build and CI can produce different versions for the same
+// commit. This warns, or fails when failOnMainBranchDivergence is set, and returns the divergence
+// (nil when the branches match or can't be compared).
+func checkMainBranchDivergence(repo *git.Repo, cfg *config.Config) (*git.MainBranchDivergence, error) {
+	mainBranch, err := repo.GetMainBranch(configuredMainBranches(cfg))
+	if err != nil {
+		// Commits with a tag don't need the main branch, the calculation reports it otherwise
+		return nil, nil
+	}
+
+	log("Comparing local '%s' branch with its remote-tracking branch...", mainBranch)
+	divergence, err := repo.GetMainBranchDivergence(mainBranch)
 	if err != nil {
-		return "", fmt.Errorf("failed to get tag on current commit: %w", err)
+		return nil, fmt.Errorf("failed to compare local and remote '%s' branch: %w", mainBranch, err)
+	}
+	if divergence == nil {
+		log("No local and remote-tracking '%s' branch pair to compare", mainBranch)
+		return nil, nil
+	}
+	if !divergence.Diverged() {
+		log("Local '%s' matches '%s'", divergence.LocalRef, divergence.RemoteRef)
+		return nil, nil
 	}
 
+	description := fmt.Sprintf("local '%s' branch is %d commit(s) ahead of and %d commit(s) behind '%s'",
+		divergence.LocalRef, divergence.Ahead, divergence.Behind, divergence.RemoteRef)
+	if cfg.FailOnMainBranchDivergence != nil && *cfg.FailOnMainBranchDivergence {
+		return nil, fmt.Errorf("the %s. Versions would depend on which of them is used. Pull or push '%s' to bring them in line, or set mainBranchSource to choose the authoritative one", description, divergence.LocalRef)
+	}
+	log("WARNING: The %s.", description)
+	log("         Using '%s' (mainBranchSource: %s), so versions may differ from builds that use '%s'.",
+		divergence.UsedRef, configuredMainBranchSource(cfg), otherRef(divergence))
+	return divergence, nil
+}
+
+// otherRef returns the main branch ref that is not used for the version calculation
+func otherRef(divergence *git.MainBranchDivergence) string {
+	if divergence.UsedRef == divergence.LocalRef {
+		return divergence.RemoteRef
+	}
+	return divergence.LocalRef
+}
+
+// calculation is the semver version calculated for a commit along with how it was derived
+type calculation struct {
+	Version         string
+	SHA             string
+	CommitTime      time.Time
+	BaseTag         string // tag the version is based on, empty when based on the initial version
+	CommitsSinceTag int
+
+	Branch       string // branch the commit was versioned as, may be empty for tagged commits
+	CIBranchUsed bool   // the branch came from CI environment variables
+	MainBranch   string
+	IsMainBranch bool
+
+	BranchCommits          int    // commits on a feature branch since it diverged from main
+	MainCommitsSinceBranch int    // commits on main since a feature branch diverged
+	Outdated               bool   // main has moved on since a feature branch diverged (see outdatedBaseCheckMode)
+	NewTagOnMain           string // most recent tag on main since a feature branch diverged, only checked in "tagged" mode
+
+	// MainBranchDivergence is set when the local and remote main branch point to different commits
+	MainBranchDivergence *git.MainBranchDivergence
+
+	repo               *git.Repo
+	mainBranchBehavior string // empty for tagged commits
+	mainCommitCount    int
+}
+
+// calculate calculates the semver version of a single commit
+// ref is the user-supplied ref the commit was resolved from, or empty when versioning HEAD
+func calculate(repo *git.Repo, cfg *config.Config, startHash plumbing.Hash, ref, branch string) (calculation, error) {
+	return calculateWithLog(repo, cfg, startHash, ref, branch, log)
+}
+
+// calculateWithLog calculates the semver version of a single commit like calculate, writing the
+// calculation details to log
+func calculateWithLog(repo *git.Repo, cfg *config.Config, startHash plumbing.Hash, ref, branch string, log logFunc) (calculation, error) {
 	tagPrefix := ""
 	if cfg.TagPrefix != nil {
 		tagPrefix = *cfg.TagPrefix
 	}
 
+	// Check for tags first - tags take precedence over everything
+	version, tag, err := taggedVersion(repo, startHash, tagPrefix, log)
+	if err != nil {
+		return calculation{}, err
+	}
 	if tag != "" {
-		log("Found git tag: %s", tag)
-
-		// Strip the configured prefix
-		version := git.StripTagPrefix(tag, tagPrefix)
-		if tagPrefix != "" && version != tag {
-			log("Stripped tag prefix '%s': %s -> %s", tagPrefix, tag, version)
-		}
-
-		// Validate that the stripped tag is valid semver
-		if !IsValidSemver(version) {
-			log("WARNING: Tag '%s' is not valid semver (after stripping prefix), ignoring tag", version)
-			log("Falling back to calculated version based on commit count")
-			// Continue with normal version calculation
-		} else {
-			log("Using tag as version: %s", version)
-			// Apply mode conversion (which handles prefix internally for JSON mode)
-			modeVersion, err := applyVersionMode(version, cfg)
-			if err != nil {
-				return "", fmt.Errorf("failed to apply version mode: %w", err)
-			}
-			// For non-JSON modes, apply prefix here
-			mode := defaults.DefaultMode
-			if cfg.Mode != nil && *cfg.Mode != "" {
-				mode = *cfg.Mode
-			}
-			if mode != defaults.ModeJson {
-				result := applyVersionPrefix(modeVersion, cfg)
-				if result != modeVersion {
-					log("Applied version prefix: %s -> %s", modeVersion, result)
-				}
-				return result, nil
-			}
-			return modeVersion, nil
-		}
-	} else {
-		log("No git tag found on current commit")
+		calc := calculation{Version: version, SHA: startHash.String(), BaseTag: tag, repo: repo}
+		calc.Branch, calc.CIBranchUsed = tagBranch(repo, cfg, ref, branch)
+		calc.IsMainBranch = git.IsMainBranch(calc.Branch, configuredMainBranches(cfg))
+		return calc, nil
 	}
 
 	// No tag found, calculate version based on branch and commit count
 	log("Calculating version based on commit count...")
 
 	// Determine main branches (with backward compatibility)
-	mainBranches := cfg.MainBranches
-	if len(mainBranches) == 0 {
-		if cfg.MainBranch != "" {
-			// Backward compatibility with old config
-			mainBranches = []string{cfg.MainBranch}
-		} else {
-			mainBranches = defaults.MainBranches
-		}
-	}
+	mainBranches := configuredMainBranches(cfg)
 	log("Configured main branches: %v", mainBranches)
 
 	// Find which main branch exists in the repo
 	mainBranch, err := repo.GetMainBranch(mainBranches)
 	if err != nil {
-		return "", fmt.Errorf("failed to find main branch: %w", err)
+		return calculation{}, fmt.Errorf("failed to find main branch: %w", err)
 	}
 	log("Using main branch: %s", mainBranch)
 
@@ -178,30 +443,193 @@ func CalculateWithConfig(cfg *config.Config) (string, error) {
 		}
 	}
 	if !validBehavior {
-		return "", fmt.Errorf("invalid mainBranchBehavior '%s': must be one of %v", mainBranchBehavior, defaults.ValidMainBranchBehaviors)
+		return calculation{}, fmt.Errorf("invalid mainBranchBehavior '%s': must be one of %v", mainBranchBehavior, defaults.ValidMainBranchBehaviors)
 	}
 
-	// Try to detect branch from CI environment first (for detached HEAD states in CI)
 	var currentBranch string
-	ciBranch, detected := ci.DetectBranch(cfg)
-	if detected {
-		log("CI branch detected: %s", ciBranch)
-		currentBranch = ciBranch
+	var ciBranchUsed bool
+	// currentHash is the tip of the branch being versioned, used for branch point calculations
+	currentHash := startHash
+	if ref != "" {
+		// An explicit ref is versioned as-is; CI environment variables describe HEAD, not the ref
+		currentBranch, err = branchForRef(repo, ref, branch, startHash, mainBranch)
+		if err != nil {
+			return calculation{}, err
+		}
+		log("Versioning ref %s as branch: %s", ref, currentBranch)
 	} else {
-		// Fall back to git branch detection
-		var err error
-		currentBranch, err = repo.GetCurrentBranch()
+		currentBranch, ciBranchUsed, err = detectBranch(repo, cfg)
 		if err != nil {
-			return "", fmt.Errorf("failed to get current branch: %w (note: this might be because you're in detached HEAD state - enable useCIBranch if in CI environment)", err)
+			return calculation{}, err
 		}
-		log("Current git branch: %s", currentBranch)
+
+		// Prefer the branch reference over HEAD (important for CI environments)
+		// If we can't find the branch reference, fall back to HEAD
+		// This handles cases where we're in detached HEAD state
+		if branchHash, err := repo.ResolveBranch(currentBranch); err == nil {
+			currentHash = branchHash
+		}
+	}
+
+	base, err := findBase(repo, cfg, startHash, tagPrefix, log)
+	if err != nil {
+		return calculation{}, err
+	}
+
+	// Get commit count on main branch
+	mainCommitCount, err := repo.GetMainBranchCommitCount(mainBranch)
+	if err != nil {
+		return calculation{}, fmt.Errorf("failed to get commit count on main branch: %w", err)
+	}
+	log("Commit count on %s branch: %d", mainBranch, mainCommitCount)
+
+	isOnMainBranch := git.IsMainBranch(currentBranch, mainBranches)
+	calc := calculation{
+		SHA:                startHash.String(),
+		Branch:             currentBranch,
+		CIBranchUsed:       ciBranchUsed,
+		MainBranch:         mainBranch,
+		IsMainBranch:       isOnMainBranch,
+		repo:               repo,
+		mainBranchBehavior: mainBranchBehavior,
+		mainCommitCount:    mainCommitCount,
 	}
 
+	if !isOnMainBranch {
+		// On feature branch: find out how far the branch has drifted from main
+		log("On feature branch '%s', calculating prerelease version...", currentBranch)
+
+		// Calculate how many commits have been added to main since this branch diverged
+		mainCommitsSinceBranch, err := repo.GetMainBranchCommitsSinceBranchPoint(mainBranch, currentHash)
+		if err != nil {
+			return calculation{}, fmt.Errorf("failed to get main branch commits since branch point: %w", err)
+		}
+		log("Commits on main branch since branching: %d", mainCommitsSinceBranch)
+		calc.MainCommitsSinceBranch = mainCommitsSinceBranch
+
+		// Determine the outdated check mode
+		outdatedCheckMode := defaults.DefaultOutdatedCheckMode
+		if cfg.OutdatedBaseCheckMode != nil && *cfg.OutdatedBaseCheckMode != "" {
+			outdatedCheckMode = *cfg.OutdatedBaseCheckMode
+			log("Using configured outdated base check mode: %s", outdatedCheckMode)
+		} else {
+			log("Using default outdated base check mode: %s", outdatedCheckMode)
+		}
+
+		// Validate outdated check mode
+		validCheckMode := false
+		for _, valid := range defaults.ValidOutdatedCheckModes {
+			if outdatedCheckMode == valid {
+				validCheckMode = true
+				break
+			}
+		}
+		if !validCheckMode {
+			return calculation{}, fmt.Errorf("invalid outdatedBaseCheckMode '%s': must be one of %v", outdatedCheckMode, defaults.ValidOutdatedCheckModes)
+		}
+
+		// Check for outdated base based on the configured mode
+		var isOutdated bool
+		var outdatedReason string
+
+		if outdatedCheckMode == defaults.OutdatedCheckModeTagged {
+			// Check only for new tags
+			hasNewTags, newTag, err := repo.CheckMainBranchHasNewTagsSinceBranchPoint(mainBranch, currentHash)
+			if err != nil {
+				// Don't fail on this check, just log the error
+				log("Warning: failed to check for new tags on main branch: %v", err)
+			} else if hasNewTags {
+				isOutdated = true
+				calc.NewTagOnMain = newTag
+				outdatedReason = fmt.Sprintf("new tag(s) since this branch diverged (most recent: %s)", newTag)
+			}
+		} else if outdatedCheckMode == defaults.OutdatedCheckModeAll {
+			// Check for any new commits
+			hasNewCommits, err := repo.CheckMainBranchHasNewCommitsSinceBranchPoint(mainBranch, currentHash)
+			if err != nil {
+				// Don't fail on this check, just log the error
+				log("Warning: failed to check for new commits on main branch: %v", err)
+			} else if hasNewCommits {
+				isOutdated = true
+				outdatedReason = fmt.Sprintf("%d new commit(s) since this branch diverged", mainCommitsSinceBranch)
+			}
+		}
+
+		// Handle outdated base if detected
+		calc.Outdated = isOutdated
+		if isOutdated {
+			// Determine if we should fail or just warn
+			failOnOutdated := cfg.FailOnOutdatedBase != nil && *cfg.FailOnOutdatedBase
+
+			if failOnOutdated {
+				return calculation{}, fmt.Errorf("the '%s' branch has %s. This branch is calculating versions based on an outdated '%s' branch. Rebase or merge from '%s' to continue", mainBranch, outdatedReason, mainBranch, mainBranch)
+			} else {
+				log("WARNING: The '%s' branch has %s.", mainBranch, outdatedReason)
+				log("         This branch is calculating versions based on an outdated '%s' branch.", mainBranch)
+				log("         Consider rebasing or merging from '%s' to get accurate version calculations.", mainBranch)
+			}
+		}
+
+		branchCommitCount, err := repo.GetCommitCountSinceBranchPoint(mainBranch, currentHash)
+		if err != nil {
+			return calculation{}, fmt.Errorf("failed to get commit count since branch point: %w", err)
+		}
+		calc.BranchCommits = branchCommitCount
+		log("Commits on feature branch since branching: %d", branchCommitCount)
+	}
+
+	if err := calc.applyBase(base, isOnMainBranch, log); err != nil {
+		return calculation{}, err
+	}
+	return calc, nil
+}
+
+// baseVersion is the version a calculation counts from: the most recent tag in the history of the
+// commit, or the initial version when there is none
+type baseVersion struct {
+	version         Version
+	tag             string // empty for the initial version
+	commitsSinceTag int
+}
+
+// taggedVersion returns the version of the tag on a commit and the tag, or an empty tag when the
+// commit has no tag that is valid semver after stripping the tag prefix
+func taggedVersion(repo *git.Repo, hash plumbing.Hash, tagPrefix string, log logFunc) (string, string, error) {
+	log("Checking for git tags on current commit...")
+	tag, err := repo.GetTagOnCommit(hash, tagPrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get tag on current commit: %w", err)
+	}
+	if tag == "" {
+		log("No git tag found on current commit")
+		return "", "", nil
+	}
+	log("Found git tag: %s", tag)
+
+	// Strip the configured prefix
+	version := git.StripTagPrefix(tag, tagPrefix)
+	if tagPrefix != "" && version != tag {
+		log("Stripped tag prefix '%s': %s -> %s", tagPrefix, tag, version)
+	}
+
+	// Validate that the stripped tag is valid semver
+	if !IsValidSemver(version) {
+		log("WARNING: Tag '%s' is not valid semver (after stripping prefix), ignoring tag", version)
+		log("Falling back to calculated version based on commit count")
+		return "", "", nil
+	}
+	log("Using tag as version: %s", version)
+	return version, tag, nil
+}
+
+// findBase returns the version a commit's version counts from: the most recent tag with the tag
+// prefix in its history, or the configured initial version
+func findBase(repo *git.Repo, cfg *config.Config, hash plumbing.Hash, tagPrefix string, log logFunc) (baseVersion, error) {
 	// Check for most recent tag in history
 	log("Looking for most recent tag in commit history...")
-	mostRecentTag, commitsSinceTag, err := repo.GetMostRecentTag(tagPrefix)
+	mostRecentTag, commitsSinceTag, err := repo.GetMostRecentTag(hash, tagPrefix)
 	if err != nil {
-		return "", fmt.Errorf("failed to get most recent tag: %w", err)
+		return baseVersion{}, fmt.Errorf("failed to get most recent tag: %w", err)
 	}
 
 	// Determine the initial version to use when no tags exist
@@ -216,77 +644,65 @@ func CalculateWithConfig(cfg *config.Config) (string, error) {
 	// Parse and validate the initial version
 	initialVersion, err := parseVersion(initialVersionStr)
 	if err != nil {
-		return "", fmt.Errorf("invalid initialVersion '%s': %w", initialVersionStr, err)
+		return baseVersion{}, fmt.Errorf("invalid initialVersion '%s': %w", initialVersionStr, err)
 	}
 	if !IsValidSemver(initialVersionStr) {
-		return "", fmt.Errorf("initialVersion '%s' is not valid semver", initialVersionStr)
+		return baseVersion{}, fmt.Errorf("initialVersion '%s' is not valid semver", initialVersionStr)
 	}
 
-	var baseVersion Version
-	var useTagAsBase bool
-	var tagNotInBranchHistory bool
-	if mostRecentTag != "" {
-		// GetMostRecentTag now only returns tags in the current branch's history
-		log("Found most recent tag in history: %s (%d commits ago)", mostRecentTag, commitsSinceTag)
-		tagNotInBranchHistory = false
-
-		// Strip prefix and validate
-		strippedTag := git.StripTagPrefix(mostRecentTag, tagPrefix)
-		if tagPrefix != "" && strippedTag != mostRecentTag {
-			log("Stripped tag prefix '%s': %s -> %s", tagPrefix, mostRecentTag, strippedTag)
-		}
-
-		if !IsValidSemver(strippedTag) {
-			log("WARNING: Most recent tag '%s' is not valid semver (after stripping prefix), ignoring", strippedTag)
-			log("Falling back to commit-count-based versioning with initial version %s", initialVersionStr)
-			baseVersion = initialVersion
-			useTagAsBase = false
-			mostRecentTag = "" // Clear it so we use commit count
-		} else {
-			// Parse the version from the tag
-			parsedVersion, err := parseVersion(strippedTag)
-			if err != nil {
-				log("WARNING: Failed to parse version from tag '%s': %v", strippedTag, err)
-				log("Falling back to commit-count-based versioning with initial version %s", initialVersionStr)
-				baseVersion = initialVersion
-				useTagAsBase = false
-				mostRecentTag = "" // Clear it so we use commit count
-			} else {
-				log("Using tag '%s' as base version", strippedTag)
-				baseVersion = parsedVersion
-				useTagAsBase = true
-			}
-		}
-	} else {
+	if mostRecentTag == "" {
 		log("No tags found in commit history, using initial version %s", initialVersionStr)
-		baseVersion = initialVersion
-		useTagAsBase = false
-		tagNotInBranchHistory = false
+		return baseVersion{version: initialVersion, commitsSinceTag: commitsSinceTag}, nil
 	}
 
-	// Get commit count on main branch
-	mainCommitCount, err := repo.GetMainBranchCommitCount(mainBranch)
-	if err != nil {
-		return "", fmt.Errorf("failed to get commit count on main branch: %w", err)
+	// GetMostRecentTag only returns tags in the current branch's history
+	log("Found most recent tag in history: %s (%d commits ago)", mostRecentTag, commitsSinceTag)
+
+	// Strip prefix and validate
+	strippedTag := git.StripTagPrefix(mostRecentTag, tagPrefix)
+	if tagPrefix != "" && strippedTag != mostRecentTag {
+		log("Stripped tag prefix '%s': %s -> %s", tagPrefix, mostRecentTag, strippedTag)
 	}
-	log("Commit count on %s branch: %d", mainBranch, mainCommitCount)
 
-	version := baseVersion
+	if !IsValidSemver(strippedTag) {
+		log("WARNING: Most recent tag '%s' is not valid semver (after stripping prefix), ignoring", strippedTag)
+		log("Falling back to commit-count-based versioning with initial version %s", initialVersionStr)
+		return baseVersion{version: initialVersion, commitsSinceTag: commitsSinceTag}, nil
+	}
 
-	isOnMainBranch := git.IsMainBranch(currentBranch, mainBranches)
+	// Parse the version from the tag
+	parsedVersion, err := parseVersion(strippedTag)
+	if err != nil {
+		log("WARNING: Failed to parse version from tag '%s': %v", strippedTag, err)
+		log("Falling back to commit-count-based versioning with initial version %s", initialVersionStr)
+		return baseVersion{version: initialVersion, commitsSinceTag: commitsSinceTag}, nil
+	}
+	log("Using tag '%s' as base version", strippedTag)
+	return baseVersion{version: parsedVersion, tag: mostRecentTag, commitsSinceTag: commitsSinceTag}, nil
+}
 
-	if isOnMainBranch {
+// applyBase sets the version of an untagged commit, counting from base
+// Main branch commits (onMain) get a release, or a "pre" prerelease with mainBranchBehavior "pre".
+// Feature branch commits get BASE.X-branchname.Y, using the branch details of the calculation.
+func (c *calculation) applyBase(base baseVersion, onMain bool, log logFunc) error {
+	version := base.version
+	useTagAsBase := base.tag != ""
+	commitsSinceTag := base.commitsSinceTag
+	c.BaseTag = base.tag
+	c.CommitsSinceTag = commitsSinceTag
+
+	if onMain {
 		// On main branch
 		log("On main branch, calculating version...")
 
-		if mainBranchBehavior == "pre" {
+		if c.mainBranchBehavior == "pre" {
 			// In "pre" mode, non-tagged commits create prerelease versions
 			log("Main branch behavior is 'pre': generating prerelease version")
 
 			if useTagAsBase {
 				// We have a tag in history
 				// Determine the next version and create prerelease
-				version.Patch = baseVersion.Patch + commitsSinceTag
+				version.Patch = base.version.Patch + commitsSinceTag
 				if commitsSinceTag > 0 {
 					// There are commits since the tag, create prerelease
 					version.Prerelease = defaults.PrereleaseID
@@ -298,9 +714,9 @@ func CalculateWithConfig(cfg *config.Config) (string, error) {
 				}
 			} else {
 				// No tags in history
-				commitCount, err := repo.GetCommitCount()
+				commitCount, err := c.repo.GetCommitCount(plumbing.NewHash(c.SHA))
 				if err != nil {
-					return "", fmt.Errorf("failed to get commit count: %w", err)
+					return fmt.Errorf("failed to get commit count: %w", err)
 				}
 				// First commit gets initial version as prerelease: 1.0.0-pre.0
 				// Subsequent commits increment: 1.0.0-pre.1, 1.0.0-pre.2, etc.
@@ -316,9 +732,9 @@ func CalculateWithConfig(cfg *config.Config) (string, error) {
 				log("Incremented patch version by %d commits since tag: %s", commitsSinceTag, version.String())
 			} else {
 				// No valid tags in history, use commit count from start
-				commitCount, err := repo.GetCommitCount()
+				commitCount, err := c.repo.GetCommitCount(plumbing.NewHash(c.SHA))
 				if err != nil {
-					return "", fmt.Errorf("failed to get commit count: %w", err)
+					return fmt.Errorf("failed to get commit count: %w", err)
 				}
 				// Start from the initial version and increment by (commitCount - 1)
 				// This way, first commit gets the initial version (e.g., 0.0.1), second gets 0.0.2, etc.
@@ -332,127 +748,134 @@ func CalculateWithConfig(cfg *config.Config) (string, error) {
 		// On feature branch: version is BASE.X-branchname.Y
 		// X is the next patch version (base + 1 + commits on main since branching)
 		// Y is the number of commits on this branch since branching
-		log("On feature branch '%s', calculating prerelease version...", currentBranch)
-
-		// Calculate how many commits have been added to main since this branch diverged
-		mainCommitsSinceBranch, err := repo.GetMainBranchCommitsSinceBranchPoint(mainBranch, currentBranch)
-		if err != nil {
-			return "", fmt.Errorf("failed to get main branch commits since branch point: %w", err)
-		}
-		log("Commits on main branch since branching: %d", mainCommitsSinceBranch)
-
-		// Determine the outdated check mode
-		outdatedCheckMode := defaults.DefaultOutdatedCheckMode
-		if cfg.OutdatedBaseCheckMode != nil && *cfg.OutdatedBaseCheckMode != "" {
-			outdatedCheckMode = *cfg.OutdatedBaseCheckMode
-			log("Using configured outdated base check mode: %s", outdatedCheckMode)
+		if useTagAsBase {
+			// Start with the next patch after the tag, plus the commits on main since branching
+			version.Patch = base.version.Patch + 1 + c.MainCommitsSinceBranch
 		} else {
-			log("Using default outdated base check mode: %s", outdatedCheckMode)
+			// No tag base, use commit count (this maintains backward compatibility)
+			version.Patch = c.mainCommitCount
 		}
 
-		// Validate outdated check mode
-		validCheckMode := false
-		for _, valid := range defaults.ValidOutdatedCheckModes {
-			if outdatedCheckMode == valid {
-				validCheckMode = true
-				break
-			}
-		}
-		if !validCheckMode {
-			return "", fmt.Errorf("invalid outdatedBaseCheckMode '%s': must be one of %v", outdatedCheckMode, defaults.ValidOutdatedCheckModes)
+		sanitizedBranch := git.SanitizeBranchName(c.Branch)
+		if sanitizedBranch != c.Branch {
+			log("Sanitized branch name: %s -> %s", c.Branch, sanitizedBranch)
 		}
+		version.Prerelease = sanitizedBranch
+		version.Build = c.BranchCommits
+		log("Calculated prerelease version: %s", version.String())
+	}
 
-		// Check for outdated base based on the configured mode
-		var isOutdated bool
-		var outdatedReason string
+	c.Version = version.String()
+	return nil
+}
 
-		if outdatedCheckMode == defaults.OutdatedCheckModeTagged {
-			// Check only for new tags
-			hasNewTags, newTag, err := repo.CheckMainBranchHasNewTagsSinceBranchPoint(mainBranch, currentBranch)
-			if err != nil {
-				// Don't fail on this check, just log the error
-				log("Warning: failed to check for new tags on main branch: %v", err)
-			} else if hasNewTags {
-				isOutdated = true
-				outdatedReason = fmt.Sprintf("new tag(s) since this branch diverged (most recent: %s)", newTag)
-			}
-		} else if outdatedCheckMode == defaults.OutdatedCheckModeAll {
-			// Check for any new commits
-			hasNewCommits, err := repo.CheckMainBranchHasNewCommitsSinceBranchPoint(mainBranch, currentBranch)
-			if err != nil {
-				// Don't fail on this check, just log the error
-				log("Warning: failed to check for new commits on main branch: %v", err)
-			} else if hasNewCommits {
-				isOutdated = true
-				outdatedReason = fmt.Sprintf("%d new commit(s) since this branch diverged", mainCommitsSinceBranch)
-			}
-		}
+// forTagPrefix calculates the version of the same commit from the tags with another prefix
+// Only the tags are looked up again: the branch, drift and commit counts are those of c. A commit
+// that c found tagged is a release, so it is counted like a main branch release.
+func (c calculation) forTagPrefix(cfg *config.Config, tagPrefix string, log logFunc) (calculation, error) {
+	hash := plumbing.NewHash(c.SHA)
+	prefixed := c
+	version, tag, err := taggedVersion(c.repo, hash, tagPrefix, log)
+	if err != nil {
+		return calculation{}, err
+	}
+	if tag != "" {
+		prefixed.Version, prefixed.BaseTag, prefixed.CommitsSinceTag = version, tag, 0
+		return prefixed, nil
+	}
 
-		// Handle outdated base if detected
-		if isOutdated {
-			// Determine if we should fail or just warn
-			failOnOutdated := cfg.FailOnOutdatedBase != nil && *cfg.FailOnOutdatedBase
+	base, err := findBase(c.repo, cfg, hash, tagPrefix, log)
+	if err != nil {
+		return calculation{}, err
+	}
+	if err := prefixed.applyBase(base, c.IsMainBranch || c.MainBranch == "", log); err != nil {
+		return calculation{}, err
+	}
+	return prefixed, nil
+}
 
-			if failOnOutdated {
-				return "", fmt.Errorf("the '%s' branch has %s. This branch is calculating versions based on an outdated '%s' branch. Rebase or merge from '%s' to continue", mainBranch, outdatedReason, mainBranch, mainBranch)
-			} else {
-				log("WARNING: The '%s' branch has %s.", mainBranch, outdatedReason)
-				log("         This branch is calculating versions based on an outdated '%s' branch.", mainBranch)
-				log("         Consider rebasing or merging from '%s' to get accurate version calculations.", mainBranch)
-			}
-		}
+// configuredMainBranches returns the configured main branches (with backward compatibility)
+func configuredMainBranches(cfg *config.Config) []string {
+	if len(cfg.MainBranches) > 0 {
+		return cfg.MainBranches
+	}
+	if cfg.MainBranch != "" {
+		// Backward compatibility with old config
+		return []string{cfg.MainBranch}
+	}
+	return defaults.MainBranches
+}
 
-		// Calculate patch version: base + 1 (for the next version) + commits on main since branching
-		if useTagAsBase {
-			// Start with the next patch after the tag
-			version.Patch = baseVersion.Patch + 1
-
-			// Only add mainCommitsSinceBranch if the tag IS in the branch history
-			// If the tag is NOT in branch history (e.g., added to main after branch diverged),
-			// we don't add mainCommitsSinceBranch because the tag already represents the latest version
-			if !tagNotInBranchHistory {
-				version.Patch += mainCommitsSinceBranch
-			}
-		} else {
-			// No tag base, use commit count (this maintains backward compatibility)
-			version.Patch = mainCommitCount
-		}
+// detectBranch returns the branch being built, preferring the CI environment over the git HEAD
+// The boolean result is true when the branch came from the CI environment
+func detectBranch(repo *git.Repo, cfg *config.Config) (string, bool, error) {
+	// Try to detect branch from CI environment first (for detached HEAD states in CI)
+	ciBranch, detected := ci.DetectBranch(cfg)
+	if detected {
+		log("CI branch detected: %s", ciBranch)
+		return ciBranch, true, nil
+	}
 
-		branchCommitCount, err := repo.GetCommitCountSinceBranchPoint(mainBranch, currentBranch)
-		if err != nil {
-			return "", fmt.Errorf("failed to get commit count since branch point: %w", err)
-		}
+	// Fall back to git branch detection
+	currentBranch, err := repo.GetCurrentBranch()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get current branch: %w (note: this might be because you're in detached HEAD state - enable useCIBranch if in CI environment)", err)
+	}
+	log("Current git branch: %s", currentBranch)
+	return currentBranch, false, nil
+}
 
-		sanitizedBranch := git.SanitizeBranchName(currentBranch)
-		if sanitizedBranch != currentBranch {
-			log("Sanitized branch name: %s -> %s", currentBranch, sanitizedBranch)
+// tagBranch returns the branch a tagged commit is being built on, if it can be determined
+// The version of a tagged commit doesn't depend on the branch, so it is only informational and
+// a detached HEAD (common when building tags) simply leaves it empty
+func tagBranch(repo *git.Repo, cfg *config.Config, ref, branch string) (string, bool) {
+	if ref != "" {
+		if branch != "" {
+			return branch, false
 		}
-		version.Prerelease = sanitizedBranch
-		version.Build = branchCommitCount
-		log("Commits on feature branch since branching: %d", branchCommitCount)
-		log("Calculated prerelease version: %s", version.String())
+		refBranch, _ := repo.BranchForRef(ref)
+		return refBranch, false
 	}
 
-	// Apply mode conversion (which handles prefix internally for JSON mode)
-	modeVersion, err := applyVersionMode(version.String(), cfg)
+	currentBranch, ciBranchUsed, err := detectBranch(repo, cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to apply version mode: %w", err)
+		return "", false
 	}
-	// For non-JSON modes, apply prefix here
-	mode := defaults.DefaultMode
-	if cfg.Mode != nil && *cfg.Mode != "" {
-		mode = *cfg.Mode
+	return currentBranch, ciBranchUsed
+}
+
+// branchForRef determines which branch a ref should be versioned as
+// An explicitly requested branch always wins. Otherwise a ref that names a branch is versioned as
+// that branch, and a commit that is part of the main branch history is versioned as main.
+func branchForRef(repo *git.Repo, ref, branch string, hash plumbing.Hash, mainBranch string) (string, error) {
+	if branch != "" {
+		return branch, nil
 	}
-	if mode != defaults.ModeJson {
-		result := applyVersionPrefix(modeVersion, cfg)
-		if result != modeVersion {
-			log("Applied version prefix: %s -> %s", modeVersion, result)
-		}
-		log("Final version: %s", result)
-		return result, nil
+	if refBranch, ok := repo.BranchForRef(ref); ok {
+		return refBranch, nil
 	}
-	log("Final version: %s", modeVersion)
-	return modeVersion, nil
+
+	mainHash, err := repo.ResolveMainBranch(mainBranch)
+	if err != nil {
+		return "", err
+	}
+	onMain, err := repo.IsAncestor(hash, mainHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if %s is on %s: %w", ref, mainBranch, err)
+	}
+	if !onMain {
+		return "", fmt.Errorf("cannot determine the branch for ref '%s' because it is not part of the '%s' branch history. Use --branch to specify it", ref, mainBranch)
+	}
+	return mainBranch, nil
+}
+
+// isJSONMode reports whether a mode outputs a JSON object rather than a single version
+func isJSONMode(mode string) bool {
+	switch mode {
+	case defaults.ModeJson, defaults.ModeDotnet, defaults.ModeNpm, defaults.ModeHelm:
+		return true
+	}
+	return false
 }
 
 // applyVersionPrefix adds the configured version prefix to the version string
@@ -463,8 +886,10 @@ func applyVersionPrefix(version string, cfg *config.Config) string {
 	return version
 }
 
-// applyVersionMode converts the version to the configured mode format
-func applyVersionMode(version string, cfg *config.Config) (string, error) {
+// applyVersionMode converts the calculated version to the configured mode format
+func applyVersionMode(result *Result, cfg *config.Config) (string, error) {
+	version := result.Semver
+
 	mode := defaults.DefaultMode
 	if cfg.Mode != nil && *cfg.Mode != "" {
 		mode = *cfg.Mode
@@ -488,53 +913,90 @@ func applyVersionMode(version string, cfg *config.Config) (string, error) {
 	// Apply mode conversion
 	switch mode {
 	case defaults.ModeJson:
-		// Convert to PEP 440 format
-		pep440Version, err := ConvertToPEP440(version)
-		if err != nil {
-			return "", fmt.Errorf("failed to convert to PEP 440: %w", err)
-		}
-
-		// Apply version prefix for the "WithPrefix" fields
-		semverWithPrefix := applyVersionPrefix(version, cfg)
-		pep440WithPrefix := applyVersionPrefix(pep440Version, cfg)
-
-		// A version is a release if it has no prerelease identifier
-		isRelease := !strings.Contains(version, "-")
-
-		// Parse version to extract major, minor, patch
-		parsedVersion, err := parseVersion(version)
-		if err != nil {
-			return "", fmt.Errorf("failed to parse version for JSON output: %w", err)
-		}
-
-		output := VersionOutput{
-			Semver:           version,
-			SemverWithPrefix: semverWithPrefix,
-			Pep440:           pep440Version,
-			Pep440WithPrefix: pep440WithPrefix,
-			Major:            parsedVersion.Major,
-			Minor:            parsedVersion.Minor,
-			Patch:            parsedVersion.Patch,
-			IsRelease:        isRelease,
+		if result.pep440Err != nil {
+			return "", fmt.Errorf("failed to convert to PEP 440: %w", result.pep440Err)
 		}
+		output := newVersionOutput(result)
 
 		jsonBytes, err := json.Marshal(output)
 		if err != nil {
 			return "", fmt.Errorf("failed to marshal JSON output: %w", err)
 		}
 
-		log("Generated JSON output with semver=%s, semverWithPrefix=%s, pep440=%s, pep440WithPrefix=%s, major=%d, minor=%d, patch=%d, isRelease=%v",
-			version, semverWithPrefix, pep440Version, pep440WithPrefix, parsedVersion.Major, parsedVersion.Minor, parsedVersion.Patch, isRelease)
+		log("Generated JSON output with semver=%s, semverWithPrefix=%s, pep440=%s, pep440WithPrefix=%s, major=%d, minor=%d, patch=%d, isRelease=%v, branch=%s, sha=%s, baseTag=%s",
+			output.Semver, output.SemverWithPrefix, output.Pep440, output.Pep440WithPrefix, output.Major, output.Minor, output.Patch, output.IsRelease, output.Branch, output.SHA, output.BaseTag)
 		return string(jsonBytes), nil
 	case defaults.ModePep440:
-		pep440Version, err := ConvertToPEP440(version)
-		if err != nil {
-			return "", fmt.Errorf("failed to convert to PEP 440: %w", err)
+		if result.pep440Err != nil {
+			return "", fmt.Errorf("failed to convert to PEP 440: %w", result.pep440Err)
 		}
+		pep440Version := result.Pep440
 		if pep440Version != version {
 			log("Converted to PEP 440 format: %s -> %s", version, pep440Version)
 		}
 		return pep440Version, nil
+	case defaults.ModeMaven:
+		style, err := mavenSnapshotStyle(cfg)
+		if err != nil {
+			return "", err
+		}
+		mavenVersion, err := ConvertToMaven(result, style)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert to Maven: %w", err)
+		}
+		if err := validateMavenOrdering(mavenVersion, result); err != nil {
+			return "", err
+		}
+		if mavenVersion != version {
+			log("Converted to Maven format: %s -> %s", version, mavenVersion)
+		}
+		return mavenVersion, nil
+	case defaults.ModeDeb, defaults.ModeRpm:
+		packageVersion, err := ConvertToPackageVersion(version, cfg)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert to a %s version: %w", mode, err)
+		}
+		if err := validatePackageOrdering(result, mode); err != nil {
+			return "", err
+		}
+		if packageVersion != version {
+			log("Converted to %s format: %s -> %s", mode, version, packageVersion)
+		}
+		return packageVersion, nil
+	case defaults.ModeDotnet:
+		versions, err := NewDotnetVersions(result, cfg)
+		if err != nil {
+			return "", err
+		}
+		jsonBytes, err := json.Marshal(versions)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal .NET versions: %w", err)
+		}
+		log("Generated .NET versions with assemblyVersion=%s, fileVersion=%s, informationalVersion=%s, nugetVersion=%s",
+			versions.AssemblyVersion, versions.FileVersion, versions.InformationalVersion, versions.NuGetVersion)
+		return string(jsonBytes), nil
+	case defaults.ModeNpm:
+		npmVersion, err := NewNpmVersion(result, cfg)
+		if err != nil {
+			return "", err
+		}
+		jsonBytes, err := json.Marshal(npmVersion)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal npm version: %w", err)
+		}
+		log("Generated npm version %s with dist-tag %s", npmVersion.Version, npmVersion.DistTag)
+		return string(jsonBytes), nil
+	case defaults.ModeHelm:
+		versions, err := NewHelmVersions(result, cfg)
+		if err != nil {
+			return "", err
+		}
+		jsonBytes, err := json.Marshal(versions)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal Helm versions: %w", err)
+		}
+		log("Generated Helm chart version %s with appVersion %s", versions.Version, versions.AppVersion)
+		return string(jsonBytes), nil
 	case defaults.ModeSemver:
 		// No conversion needed for semver
 		return version, nil